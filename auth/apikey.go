@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/asvins/router/errors"
+)
+
+// Source is where the API key is read from
+type Source int
+
+const (
+	// Header reads the key from a request header
+	Header Source = iota
+	// Query reads the key from a query string parameter
+	Query
+	// Cookie reads the key from a cookie
+	Cookie
+)
+
+// DefaultAPIKeyName is the default header, query parameter or cookie name of the key
+const DefaultAPIKeyName = "X-API-Key"
+
+// KeyLookup returns the principal the key belongs to, or false if the key is invalid
+type KeyLookup func(key string) (*Principal, bool)
+
+// APIKey is an interceptor authenticating requests with an API key
+type APIKey struct {
+	// Source of the key
+	Source Source
+
+	// Name of the header, query parameter or cookie. Defaults to X-API-Key
+	Name string
+
+	// Lookup finds the principal of the key
+	Lookup KeyLookup
+}
+
+// NewAPIKey = constructor for APIKey
+func NewAPIKey(source Source, name string, lookup KeyLookup) *APIKey {
+	return &APIKey{Source: source, Name: name, Lookup: lookup}
+}
+
+// Intercept is the Interceptor interface implementation
+func (a *APIKey) Intercept(rw http.ResponseWriter, r *http.Request) errors.Http {
+	key := a.key(r)
+	if key == "" {
+		return a.unauthorized("Missing API key")
+	}
+
+	principal, ok := a.Lookup(key)
+	if !ok {
+		return a.unauthorized("Invalid API key")
+	}
+
+	SetPrincipal(r, principal)
+	return nil
+}
+
+// key reads the key from the configured source
+func (a *APIKey) key(r *http.Request) string {
+	switch a.Source {
+	case Query:
+		return r.URL.Query().Get(a.name())
+	case Cookie:
+		if cookie, err := r.Cookie(a.name()); err == nil {
+			return cookie.Value
+		}
+		return ""
+	default:
+		return r.Header.Get(a.name())
+	}
+}
+
+// name returns the configured name or the default one
+func (a *APIKey) name() string {
+	if a.Name == "" {
+		return DefaultAPIKeyName
+	}
+	return a.Name
+}
+
+// unauthorized returns the error asking the client to authenticate, telling where the key goes
+func (a *APIKey) unauthorized(msg string) errors.Http {
+	in := [...]string{Header: "header", Query: "query", Cookie: "cookie"}[a.Source]
+	return errors.Unauthorized(msg).WithHeader("WWW-Authenticate", `APIKey in="`+in+`", name="`+a.name()+`"`)
+}
+
+// StaticKeys returns a KeyLookup for a fixed map of key to principal. Keys are compared in constant time
+func StaticKeys(keys map[string]*Principal) KeyLookup {
+	return func(key string) (*Principal, bool) {
+		var found *Principal
+		for k, principal := range keys {
+			// every key is compared so the time doesn't tell how close the key is
+			if secureCompare(k, key) {
+				found = principal
+			}
+		}
+		return found, found != nil
+	}
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/asvins/router"
+)
+
+// Principal is the authenticated user or client of a request
+type Principal struct {
+	Name   string
+	Roles  []string
+	Scopes []string
+
+	// Claims are extra attributes of the principal, e.g. the claims of a JWT
+	Claims map[string]interface{}
+}
+
+// principalKey is the key of the principal in the request
+type principalKey struct{}
+
+// SetPrincipal stores the authenticated principal of rq, see router.SetValue
+func SetPrincipal(rq *http.Request, principal *Principal) {
+	router.SetValue(rq, principalKey{}, principal)
+}
+
+// FromRequest returns the authenticated principal of rq, or nil if it wasn't authenticated
+func FromRequest(rq *http.Request) *Principal {
+	principal, _ := router.Value(rq, principalKey{}).(*Principal)
+	return principal
+}
+
+// secureCompare compares a and b in constant time, regardless of their content and length
+func secureCompare(a, b string) bool {
+	hashA := sha256.Sum256([]byte(a))
+	hashB := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(hashA[:], hashB[:]) == 1
+}
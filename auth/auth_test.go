@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/asvins/router"
+)
+
+func newRouter(interceptor router.Interceptor) *router.Router {
+	r := router.NewRouter()
+	r.AddRoute("/me", router.GET, func(w http.ResponseWriter, rq *http.Request) {
+		if principal := FromRequest(rq); principal != nil {
+			w.Write([]byte(principal.Name))
+		}
+	}, interceptor)
+	return r
+}
+
+func TestBasic(t *testing.T) {
+	r := newRouter(NewBasic("orders", StaticCredentials(map[string]string{"alice": "secret"})))
+
+	cases := []struct {
+		user, pass string
+		set        bool
+		code       int
+	}{
+		{"alice", "secret", true, http.StatusOK},
+		{"alice", "wrong", true, http.StatusUnauthorized},
+		{"bob", "secret", true, http.StatusUnauthorized},
+		{"", "", false, http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		rq := httptest.NewRequest("GET", "/me", nil)
+		if c.set {
+			rq.SetBasicAuth(c.user, c.pass)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, rq)
+
+		if w.Code != c.code {
+			t.Error("Status for", c.user, c.pass, "should be", c.code, " Got", w.Code)
+		}
+		if c.code == http.StatusOK && w.Body.String() != "alice" {
+			t.Error("Principal should be alice Got", w.Body.String())
+		}
+		if auth := w.Header().Get("WWW-Authenticate"); c.code == http.StatusUnauthorized && auth != `Basic realm="orders", charset="UTF-8"` {
+			t.Error("WWW-Authenticate should be set Got", auth)
+		}
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	lookup := StaticKeys(map[string]*Principal{"k-123": {Name: "billing"}})
+
+	cases := []struct {
+		source Source
+		set    func(rq *http.Request)
+		code   int
+	}{
+		{Header, func(rq *http.Request) { rq.Header.Set("X-API-Key", "k-123") }, http.StatusOK},
+		{Header, func(rq *http.Request) { rq.Header.Set("X-API-Key", "k-124") }, http.StatusUnauthorized},
+		{Header, func(rq *http.Request) {}, http.StatusUnauthorized},
+		{Query, func(rq *http.Request) { rq.URL.RawQuery = "X-API-Key=k-123" }, http.StatusOK},
+		{Cookie, func(rq *http.Request) { rq.AddCookie(&http.Cookie{Name: "X-API-Key", Value: "k-123"}) }, http.StatusOK},
+		{Cookie, func(rq *http.Request) { rq.Header.Set("X-API-Key", "k-123") }, http.StatusUnauthorized},
+	}
+
+	for i, c := range cases {
+		r := newRouter(NewAPIKey(c.source, "", lookup))
+		rq := httptest.NewRequest("GET", "/me", nil)
+		c.set(rq)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, rq)
+
+		if w.Code != c.code {
+			t.Error("Status of case", i, "should be", c.code, " Got", w.Code)
+		}
+		if c.code == http.StatusOK && w.Body.String() != "billing" {
+			t.Error("Principal should be billing Got", w.Body.String())
+		}
+		if c.code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Error("WWW-Authenticate should be set for case", i)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/asvins/router/errors"
+)
+
+// Authorizer decides if principal meets requirement. principal is never nil
+type Authorizer interface {
+	Authorize(rq *http.Request, principal *Principal, requirement *Requirement) bool
+}
+
+// AuthorizerFunc adapts a function to the Authorizer interface
+type AuthorizerFunc func(rq *http.Request, principal *Principal, requirement *Requirement) bool
+
+// Authorize is the Authorizer interface implementation
+func (f AuthorizerFunc) Authorize(rq *http.Request, principal *Principal, requirement *Requirement) bool {
+	return f(rq, principal, requirement)
+}
+
+// DefaultAuthorizer requires the principal to have every scope and at least one of the roles of the requirement
+var DefaultAuthorizer Authorizer = AuthorizerFunc(func(rq *http.Request, principal *Principal, requirement *Requirement) bool {
+	if len(missingScopes(principal, requirement.Scopes)) > 0 {
+		return false
+	}
+	if len(requirement.Roles) == 0 {
+		return true
+	}
+
+	for _, role := range requirement.Roles {
+		if containsString(principal.Roles, role) {
+			return true
+		}
+	}
+	return false
+})
+
+// Requirement is an interceptor checking the roles and scopes of the principal authenticated by a previous interceptor.
+// Add it to a route, or as a base interceptor to a group of routes after the authentication one.
+// It implements router.Describer, so the requirements are listed by Router.Routes
+type Requirement struct {
+	Roles  []string
+	Scopes []string
+
+	// Authorizer evaluates the requirement. Defaults to DefaultAuthorizer
+	Authorizer Authorizer
+
+	// Realm sent in the WWW-Authenticate header when scopes are missing
+	Realm string
+}
+
+// RequireRoles returns a Requirement of at least one of roles
+func RequireRoles(roles ...string) *Requirement {
+	return &Requirement{Roles: roles}
+}
+
+// RequireScopes returns a Requirement of all the scopes
+func RequireScopes(scopes ...string) *Requirement {
+	return &Requirement{Scopes: scopes}
+}
+
+// WithAuthorizer sets the Authorizer of the requirement
+func (q *Requirement) WithAuthorizer(authorizer Authorizer) *Requirement {
+	q.Authorizer = authorizer
+	return q
+}
+
+// Intercept is the Interceptor interface implementation.
+// Requests without a principal are Unauthorized and the ones that don't meet the requirement are Forbidden
+func (q *Requirement) Intercept(rw http.ResponseWriter, r *http.Request) errors.Http {
+	principal := FromRequest(r)
+	if principal == nil {
+		return errors.Unauthorized("Authentication required")
+	}
+
+	authorizer := q.Authorizer
+	if authorizer == nil {
+		authorizer = DefaultAuthorizer
+	}
+	if authorizer.Authorize(r, principal, q) {
+		return nil
+	}
+
+	if len(q.Scopes) > 0 && len(missingScopes(principal, q.Scopes)) > 0 {
+		return InsufficientScopeError(q.Realm, q.Scopes)
+	}
+	return errors.Forbidden("Insufficient permissions").WithCode("insufficient_permissions")
+}
+
+// Requirements is the router.Describer interface implementation, e.g. "role:admin" and "scope:orders:write"
+func (q *Requirement) Requirements() []string {
+	requirements := make([]string, 0, len(q.Roles)+len(q.Scopes))
+	for _, role := range q.Roles {
+		requirements = append(requirements, "role:"+role)
+	}
+	for _, scope := range q.Scopes {
+		requirements = append(requirements, "scope:"+scope)
+	}
+	return requirements
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asvins/router"
+	"github.com/asvins/router/errors"
+)
+
+// principalInterceptor authenticates every request as its principal
+type principalInterceptor struct {
+	principal *Principal
+}
+
+func (p principalInterceptor) Intercept(rw http.ResponseWriter, r *http.Request) errors.Http {
+	if p.principal != nil {
+		SetPrincipal(r, p.principal)
+	}
+	return nil
+}
+
+func TestRequirement(t *testing.T) {
+	cases := []struct {
+		principal   *Principal
+		requirement *Requirement
+		code        int
+	}{
+		{&Principal{Scopes: []string{"orders:read", "orders:write"}}, RequireScopes("orders:write"), http.StatusOK},
+		{&Principal{Scopes: []string{"orders:read"}}, RequireScopes("orders:read", "orders:write"), http.StatusForbidden},
+		{&Principal{Roles: []string{"editor"}}, RequireRoles("admin", "editor"), http.StatusOK},
+		{&Principal{Roles: []string{"viewer"}}, RequireRoles("admin", "editor"), http.StatusForbidden},
+		{nil, RequireRoles("admin"), http.StatusUnauthorized},
+		{&Principal{Name: "alice"}, RequireRoles("admin").WithAuthorizer(AuthorizerFunc(func(rq *http.Request, p *Principal, q *Requirement) bool {
+			return p.Name == "alice"
+		})), http.StatusOK},
+	}
+
+	for i, c := range cases {
+		r := router.NewRouter()
+		r.AddBaseInterceptor("/api", principalInterceptor{c.principal})
+		r.AddRoute("/api/orders", router.POST, func(w http.ResponseWriter, rq *http.Request) {}, c.requirement)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", "/api/orders", nil))
+		if w.Code != c.code {
+			t.Error("Status of case", i, "should be", c.code, " Got", w.Code)
+		}
+	}
+}
+
+func TestRequirementRoutes(t *testing.T) {
+	r := router.NewRouter()
+	r.AddBaseInterceptor("/admin", RequireRoles("admin"))
+	r.AddRoute("/admin/orders", router.POST, func(w http.ResponseWriter, rq *http.Request) {}, RequireScopes("orders:write"))
+
+	requirements := strings.Join(r.Routes()[0].Requirements, ",")
+	if requirements != "role:admin,scope:orders:write" {
+		t.Error("Routes should list the group and route requirements Got", requirements)
+	}
+
+	w := httptest.NewRecorder()
+	rq := httptest.NewRequest("POST", "/admin/orders", nil)
+	r.AddBaseInterceptor("/", principalInterceptor{&Principal{Roles: []string{"admin"}, Scopes: []string{"orders:read"}}})
+	r.ServeHTTP(w, rq)
+	if got := w.Header().Get("WWW-Authenticate"); w.Code != http.StatusForbidden || got != `Bearer error="insufficient_scope", scope="orders:write"` {
+		t.Error("Missing scopes should be Forbidden with insufficient_scope Got", w.Code, got)
+	}
+}
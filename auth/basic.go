@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/asvins/router/errors"
+)
+
+// CredentialChecker returns the principal of the username and password, or false if they are invalid
+type CredentialChecker func(username, password string) (*Principal, bool)
+
+// Basic is an interceptor authenticating requests with HTTP Basic authentication
+type Basic struct {
+	// Realm sent in the WWW-Authenticate header
+	Realm string
+
+	// Check validates the credentials
+	Check CredentialChecker
+}
+
+// NewBasic = constructor for Basic
+func NewBasic(realm string, check CredentialChecker) *Basic {
+	return &Basic{Realm: realm, Check: check}
+}
+
+// Intercept is the Interceptor interface implementation
+func (b *Basic) Intercept(rw http.ResponseWriter, r *http.Request) errors.Http {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return b.unauthorized("Missing credentials")
+	}
+
+	principal, ok := b.Check(username, password)
+	if !ok {
+		return b.unauthorized("Invalid credentials")
+	}
+
+	SetPrincipal(r, principal)
+	return nil
+}
+
+// unauthorized returns the error asking the client to authenticate
+func (b *Basic) unauthorized(msg string) errors.Http {
+	return errors.Unauthorized(msg).WithHeader("WWW-Authenticate", `Basic realm="`+b.Realm+`", charset="UTF-8"`)
+}
+
+// StaticCredentials returns a CredentialChecker for a fixed map of username to password.
+// Passwords are compared in constant time and the principal is named after the username
+func StaticCredentials(users map[string]string) CredentialChecker {
+	return func(username, password string) (*Principal, bool) {
+		valid := false
+		for user, pass := range users {
+			// every user is compared so the time doesn't tell which usernames exist
+			if secureCompare(user, username) && secureCompare(pass, password) {
+				valid = true
+			}
+		}
+
+		if !valid {
+			return nil, false
+		}
+		return &Principal{Name: username}, true
+	}
+}
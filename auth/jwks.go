@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// KeySet holds the keys JWT signatures are verified with, by key ID.
+// HS256 keys are []byte, RS256 keys *rsa.PublicKey and ES256 keys *ecdsa.PublicKey
+type KeySet struct {
+	keys []keyEntry
+}
+
+// keyEntry is a key with its ID, several keys may share an ID or have none
+type keyEntry struct {
+	kid string
+	key interface{}
+}
+
+// NewKeySet = constructor for KeySet
+func NewKeySet() *KeySet {
+	return &KeySet{}
+}
+
+// Add adds a key with its ID. Tokens without a kid header are checked against every key of their algorithm,
+// tokens with one against every key with that ID
+func (ks *KeySet) Add(kid string, key interface{}) *KeySet {
+	ks.keys = append(ks.keys, keyEntry{kid: kid, key: key})
+	return ks
+}
+
+// candidates returns the keys a token with the kid header may be signed with
+func (ks *KeySet) candidates(kid string) []interface{} {
+	keys := make([]interface{}, 0, len(ks.keys))
+	for _, entry := range ks.keys {
+		if kid == "" || entry.kid == kid {
+			keys = append(keys, entry.key)
+		}
+	}
+	return keys
+}
+
+// jwk is a JSON Web Key, RFC 7517
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKS reads a JWKS file into a KeySet
+func LoadJWKS(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS parses a JSON Web Key Set with oct, RSA and P-256 EC keys.
+// Encryption keys and keys of other types or curves are skipped
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %v", err)
+	}
+
+	ks := NewKeySet()
+	for _, k := range set.Keys {
+		if k.Use == "enc" || !k.supported() {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("jwks: key %q: %v", k.Kid, err)
+		}
+		ks.Add(k.Kid, key)
+	}
+	return ks, nil
+}
+
+// supported tells if the type and curve of k can verify tokens
+func (k jwk) supported() bool {
+	return k.Kty == "oct" || k.Kty == "RSA" || k.Kty == "EC" && k.Crv == "P-256"
+}
+
+// publicKey decodes the key material of k
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "oct":
+		return decodeSegment(k.K)
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeSegment decodes base64url without padding, as used by JWTs and JWKs
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// decodeInt decodes a base64url big endian unsigned integer
+func decodeInt(s string) (*big.Int, error) {
+	b, err := decodeSegment(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/asvins/router/errors"
+)
+
+// Bearer token error codes, RFC 6750
+const (
+	InvalidRequest    = "invalid_request"
+	InvalidToken      = "invalid_token"
+	InsufficientScope = "insufficient_scope"
+)
+
+// JWT is an interceptor authenticating requests with a JWT bearer token.
+// The principal is named after the sub claim, its scopes come from the scope (space separated) or scp claims,
+// its roles from the roles claim and all the claims are kept in Claims
+type JWT struct {
+	// Keys the signatures are verified with
+	Keys *KeySet
+
+	// Issuer required in the iss claim, if not empty
+	Issuer string
+
+	// Audience required in the aud claim, if not empty
+	Audience string
+
+	// Leeway is the clock skew allowed when checking exp and nbf
+	Leeway time.Duration
+
+	// RequireExp rejects tokens without an exp claim. NewJWT sets it
+	RequireExp bool
+
+	// Scopes every token must have, a token without them is Forbidden
+	Scopes []string
+
+	// Realm sent in the WWW-Authenticate header
+	Realm string
+
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+}
+
+// NewJWT = constructor for JWT
+func NewJWT(keys *KeySet, issuer, audience string) *JWT {
+	return &JWT{Keys: keys, Issuer: issuer, Audience: audience, Leeway: time.Minute, RequireExp: true}
+}
+
+// Claims returns the claims of the token rq was authenticated with, or nil
+func Claims(rq *http.Request) map[string]interface{} {
+	if principal := FromRequest(rq); principal != nil {
+		return principal.Claims
+	}
+	return nil
+}
+
+// Intercept is the Interceptor interface implementation
+func (j *JWT) Intercept(rw http.ResponseWriter, r *http.Request) errors.Http {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return errors.Unauthorized("Missing bearer token").WithHeader("WWW-Authenticate", challenge(j.Realm))
+	}
+
+	token := ""
+	if scheme, value, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		token = strings.TrimSpace(value)
+	}
+	if token == "" {
+		return j.bearerError(errors.BadRequest("Malformed authorization header"), InvalidRequest, "")
+	}
+
+	claims, err := j.verify(token)
+	if err != "" {
+		return j.bearerError(errors.Unauthorized(err), InvalidToken, err)
+	}
+
+	principal := principalFromClaims(claims)
+	if missing := missingScopes(principal, j.Scopes); len(missing) > 0 {
+		return InsufficientScopeError(j.Realm, j.Scopes)
+	}
+
+	SetPrincipal(r, principal)
+	return nil
+}
+
+// verify checks the signature and the registered claims of token, returning its claims or why it is invalid
+func (j *JWT) verify(token string) (map[string]interface{}, string) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, "Malformed token"
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if !decodeJSON(parts[0], &header) {
+		return nil, "Malformed token header"
+	}
+
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, "Malformed token signature"
+	}
+
+	if !j.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature) {
+		return nil, "Invalid token signature"
+	}
+
+	var claims map[string]interface{}
+	if !decodeJSON(parts[1], &claims) {
+		return nil, "Malformed token claims"
+	}
+
+	return claims, j.validate(claims)
+}
+
+// verifySignature verifies signature with the keys of kid usable with alg. The key type must match alg,
+// so an RSA public key is never used as an HMAC secret
+func (j *JWT) verifySignature(alg, kid, signed string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signed))
+
+	for _, key := range j.Keys.candidates(kid) {
+		switch key := key.(type) {
+		case []byte:
+			if alg != "HS256" {
+				continue
+			}
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(signed))
+			if hmac.Equal(signature, mac.Sum(nil)) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if alg == "RS256" && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+				return true
+			}
+		case *ecdsa.PublicKey:
+			if alg != "ES256" || len(signature) != 64 {
+				continue
+			}
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			if ecdsa.Verify(key, digest[:], r, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validate checks exp, nbf, iss and aud, returning why the claims are invalid or an empty string
+func (j *JWT) validate(claims map[string]interface{}) string {
+	now := time.Now()
+	if j.Now != nil {
+		now = j.Now()
+	}
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok && j.RequireExp {
+		return "Token without expiration"
+	}
+	if ok && !now.Before(exp.Add(j.Leeway)) {
+		return "Token expired"
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(j.Leeway).Before(nbf) {
+		return "Token not valid yet"
+	}
+	if j.Issuer != "" && claims["iss"] != j.Issuer {
+		return "Invalid token issuer"
+	}
+	if j.Audience != "" && !containsString(stringList(claims["aud"]), j.Audience) {
+		return "Invalid token audience"
+	}
+	return ""
+}
+
+// bearerError adds the error code to err and the WWW-Authenticate header
+func (j *JWT) bearerError(err errors.Http, code, description string) errors.Http {
+	return errors.Extend(err).WithCode(code).WithHeader("WWW-Authenticate", challenge(j.Realm, "error", code, "error_description", description))
+}
+
+// InsufficientScopeError is the Forbidden error for a token without the scopes required
+func InsufficientScopeError(realm string, scopes []string) errors.Http {
+	return errors.Forbidden("Insufficient scope").WithCode(InsufficientScope).
+		WithHeader("WWW-Authenticate", challenge(realm, "error", InsufficientScope, "scope", strings.Join(scopes, " ")))
+}
+
+// challenge returns a Bearer WWW-Authenticate header with the realm and the name, value pairs that aren't empty
+func challenge(realm string, params ...string) string {
+	params = append([]string{"realm", realm}, params...)
+
+	list := []string{}
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] != "" {
+			list = append(list, params[i]+`="`+params[i+1]+`"`)
+		}
+	}
+
+	if len(list) == 0 {
+		return "Bearer"
+	}
+	return "Bearer " + strings.Join(list, ", ")
+}
+
+// principalFromClaims builds the principal of a token
+func principalFromClaims(claims map[string]interface{}) *Principal {
+	principal := &Principal{Claims: claims, Roles: stringList(claims["roles"])}
+	principal.Name, _ = claims["sub"].(string)
+
+	if scope, ok := claims["scope"].(string); ok {
+		principal.Scopes = strings.Fields(scope)
+	} else {
+		principal.Scopes = stringList(claims["scp"])
+	}
+	return principal
+}
+
+// missingScopes returns the scopes of required the principal doesn't have
+func missingScopes(principal *Principal, required []string) []string {
+	missing := []string{}
+	for _, scope := range required {
+		if principal == nil || !containsString(principal.Scopes, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// decodeJSON decodes a base64url JSON segment into v
+func decodeJSON(segment string, v interface{}) bool {
+	data, err := decodeSegment(segment)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// numericDate converts a NumericDate claim
+func numericDate(v interface{}) (time.Time, bool) {
+	seconds, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
+
+// stringList converts a claim that may be a string or a list of strings
+func stringList(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// containsString tells if list has s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asvins/router"
+)
+
+func sign(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWT(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	now := time.Unix(1700000000, 0)
+
+	keys := NewKeySet().Add("hs", secret).Add("rs", &rsaKey.PublicKey).Add("es", &ecKey.PublicKey)
+	jwt := NewJWT(keys, "https://issuer.example.com", "orders")
+	jwt.Realm = "orders"
+	jwt.Now = func() time.Time { return now }
+
+	r := router.NewRouter()
+	r.AddRoute("/me", router.GET, func(w http.ResponseWriter, rq *http.Request) {
+		principal := FromRequest(rq)
+		fmt.Fprint(w, principal.Name, " ", strings.Join(principal.Scopes, ","), " ", Claims(rq)["tenant"])
+	}, jwt)
+
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":    "alice",
+			"iss":    "https://issuer.example.com",
+			"aud":    []string{"orders", "billing"},
+			"exp":    now.Add(time.Hour).Unix(),
+			"nbf":    now.Unix(),
+			"scope":  "orders:read orders:write",
+			"tenant": "acme",
+		}
+		for k, v := range changes {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	cases := []struct {
+		name   string
+		header string
+		code   int
+		auth   string
+	}{
+		{"HS256", "Bearer " + sign(t, "HS256", "hs", secret, claims(nil)), http.StatusOK, ""},
+		{"RS256", "Bearer " + sign(t, "RS256", "rs", rsaKey, claims(nil)), http.StatusOK, ""},
+		{"ES256 without kid", "Bearer " + sign(t, "ES256", "", ecKey, claims(nil)), http.StatusOK, ""},
+		{"skew", "Bearer " + sign(t, "HS256", "hs", secret, claims(map[string]interface{}{"exp": now.Add(-30 * time.Second).Unix()})), http.StatusOK, ""},
+		{"missing", "", http.StatusUnauthorized, `Bearer realm="orders"`},
+		{"scheme", "Basic YWxpY2U6c2VjcmV0", http.StatusBadRequest, `Bearer realm="orders", error="invalid_request"`},
+		{"expired", "Bearer " + sign(t, "HS256", "hs", secret, claims(map[string]interface{}{"exp": now.Add(-2 * time.Minute).Unix()})), http.StatusUnauthorized, `Bearer realm="orders", error="invalid_token", error_description="Token expired"`},
+		{"no expiration", "Bearer " + sign(t, "HS256", "hs", secret, claims(map[string]interface{}{"exp": nil})), http.StatusUnauthorized, `Bearer realm="orders", error="invalid_token", error_description="Token without expiration"`},
+		{"not before", "Bearer " + sign(t, "HS256", "hs", secret, claims(map[string]interface{}{"nbf": now.Add(2 * time.Minute).Unix()})), http.StatusUnauthorized, `Bearer realm="orders", error="invalid_token", error_description="Token not valid yet"`},
+		{"issuer", "Bearer " + sign(t, "HS256", "hs", secret, claims(map[string]interface{}{"iss": "https://evil.example.com"})), http.StatusUnauthorized, `Bearer realm="orders", error="invalid_token", error_description="Invalid token issuer"`},
+		{"audience", "Bearer " + sign(t, "HS256", "hs", secret, claims(map[string]interface{}{"aud": "billing"})), http.StatusUnauthorized, `Bearer realm="orders", error="invalid_token", error_description="Invalid token audience"`},
+		{"wrong key", "Bearer " + sign(t, "HS256", "hs", []byte("another secret"), claims(nil)), http.StatusUnauthorized, `Bearer realm="orders", error="invalid_token", error_description="Invalid token signature"`},
+		{"algorithm mismatch", "Bearer " + sign(t, "HS256", "rs", secret, claims(nil)), http.StatusUnauthorized, `Bearer realm="orders", error="invalid_token", error_description="Invalid token signature"`},
+		{"none", "Bearer " + strings.TrimSuffix(sign(t, "none", "", nil, claims(nil)), "."), http.StatusUnauthorized, `Bearer realm="orders", error="invalid_token", error_description="Malformed token"`},
+	}
+
+	for _, c := range cases {
+		rq := httptest.NewRequest("GET", "/me", nil)
+		if c.header != "" {
+			rq.Header.Set("Authorization", c.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, rq)
+
+		if w.Code != c.code {
+			t.Error("Status of", c.name, "should be", c.code, " Got", w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("WWW-Authenticate"); got != c.auth {
+			t.Error("WWW-Authenticate of", c.name, "should be", c.auth, " Got", got)
+		}
+		if c.code == http.StatusOK && w.Body.String() != "alice orders:read,orders:write acme" {
+			t.Error("Principal of", c.name, "should be alice with the scopes and claims Got", w.Body.String())
+		}
+	}
+
+	jwt.Scopes = []string{"orders:admin"}
+	rq := httptest.NewRequest("GET", "/me", nil)
+	rq.Header.Set("Authorization", "Bearer "+sign(t, "HS256", "hs", secret, claims(nil)))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, rq)
+
+	if w.Code != http.StatusForbidden {
+		t.Error("Status without the scopes should be", http.StatusForbidden, " Got", w.Code)
+	}
+	if got := w.Header().Get("WWW-Authenticate"); got != `Bearer realm="orders", error="insufficient_scope", scope="orders:admin"` {
+		t.Error("WWW-Authenticate without the scopes should have insufficient_scope Got", got)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	jwks := fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rs", "use": "sig", "n": %q, "e": "AQAB"},
+		{"kty": "EC", "kid": "es", "crv": "P-256", "x": %q, "y": %q},
+		{"kty": "oct", "kid": "hs", "k": %q},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AA", "e": "AQAB"},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "AA"},
+		{"kty": "EC", "kid": "es384", "crv": "P-384", "x": "AA", "y": "AA"}
+	]}`, encode(rsaKey.N.Bytes()), encode(ecKey.X.Bytes()), encode(ecKey.Y.Bytes()), encode([]byte("secret")))
+
+	keys, err := ParseJWKS([]byte(jwks))
+	if err != nil {
+		t.Fatal("JWKS should parse Got", err)
+	}
+	if len(keys.keys) != 3 {
+		t.Error("JWKS should have 3 signing keys Got", len(keys.keys))
+	}
+
+	jwt := &JWT{Keys: keys}
+	for kid, key := range map[string]interface{}{"rs": rsaKey, "es": ecKey, "hs": []byte("secret")} {
+		alg := map[string]string{"rs": "RS256", "es": "ES256", "hs": "HS256"}[kid]
+		if _, err := jwt.verify(sign(t, alg, kid, key, map[string]interface{}{"sub": "alice"})); err != "" {
+			t.Error("Token signed with", kid, "should be valid Got", err)
+		}
+	}
+
+	if _, err := ParseJWKS([]byte(`{"keys": [{"kty": "RSA", "n": "!", "e": "AQAB"}]}`)); err == nil {
+		t.Error("JWKS with malformed keys should fail")
+	}
+
+	// keys rotated under the same ID, or without one, are all kept
+	other := []byte("another secret")
+	keys = NewKeySet().Add("hs", other).Add("hs", []byte("secret")).Add("", []byte("unnamed"))
+	jwt = &JWT{Keys: keys}
+	for kid, key := range map[string][]byte{"hs": []byte("secret"), "": []byte("unnamed")} {
+		if _, err := jwt.verify(sign(t, "HS256", kid, key, map[string]interface{}{"sub": "alice"})); err != "" {
+			t.Error("Token signed with", string(key), "should be valid Got", err)
+		}
+	}
+}
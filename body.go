@@ -0,0 +1,129 @@
+package router
+
+import (
+	stderrors "errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/asvins/router/errors"
+)
+
+// BodyLimit is an interceptor limiting the request body to a number of bytes. Added to a group or a route,
+// it replaces the Router.MaxBodySize and the limits of the groups it's in, e.g. for an upload route.
+// A limit that isn't positive removes them
+type BodyLimit int64
+
+// Intercept is the Interceptor interface implementation
+func (l BodyLimit) Intercept(rw http.ResponseWriter, r *http.Request) errors.Http {
+	limitBody(rw, r, int64(l))
+	return nil
+}
+
+// Requirements is the Describer interface implementation
+func (l BodyLimit) Requirements() []string {
+	if l <= 0 {
+		return nil
+	}
+	return []string{"max-body-size:" + strconv.FormatInt(int64(l), 10)}
+}
+
+// limitBody limits the body of rq to n bytes. Bodies aren't rejected by their Content-Length here,
+// as a more specific limit may still replace this one, but by the router before calling the handler
+func limitBody(w http.ResponseWriter, rq *http.Request, n int64) {
+	state := getState(rq)
+	body := rq.Body
+	if state != nil && state.limited != nil && rq.Body == state.limited {
+		body = state.body
+	}
+
+	rq.Body = body
+	if n > 0 && body != nil && body != http.NoBody {
+		rq.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, body, n), state: state}
+	}
+
+	if state != nil {
+		state.body, state.limited = body, rq.Body
+		state.bodyLimit = n
+	}
+}
+
+// limitedBody records in the request state when the limit is exceeded,
+// so the error written for the request is a 413 whatever the handler returns
+type limitedBody struct {
+	io.ReadCloser
+	state *requestState
+}
+
+// Read is the io.Reader interface implementation
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if b.state != nil && stderrors.As(err, &maxBytesErr) {
+		b.state.bodyTooLarge = true
+	}
+	return n, err
+}
+
+// checkBodySize rejects the bodies whose Content-Length is over the limit in effect
+func checkBodySize(rq *http.Request) errors.Http {
+	state := getState(rq)
+	if state == nil || state.bodyLimit <= 0 || rq.ContentLength <= state.bodyLimit {
+		return nil
+	}
+	return errors.RequestEntityTooLarge("Request body too large").WithDetail("limit", state.bodyLimit)
+}
+
+// bodyError turns err into a 413 when it's caused by reading past the body limit,
+// even if the handler didn't return the read error as it is
+func bodyError(rq *http.Request, err errors.Http) errors.Http {
+	if err.Code() == http.StatusRequestEntityTooLarge {
+		return err
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	cause, _ := err.(error)
+	tooLarge := cause != nil && stderrors.As(cause, &maxBytesErr)
+	if state := getState(rq); state != nil && state.bodyTooLarge {
+		tooLarge = true
+	}
+
+	if !tooLarge {
+		return err
+	}
+	return errors.Wrap(cause, http.StatusRequestEntityTooLarge, "Request body too large")
+}
+
+// ContentTypes is an interceptor rejecting requests with a body of another media type with 415 Unsupported Media Type.
+// Types may end with a wildcard, e.g. "image/*". Requests without a body are accepted
+type ContentTypes []string
+
+// Intercept is the Interceptor interface implementation
+func (c ContentTypes) Intercept(rw http.ResponseWriter, r *http.Request) errors.Http {
+	if r.ContentLength == 0 || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err == nil {
+		for _, allowed := range c {
+			allowed = strings.ToLower(allowed)
+			if allowed == mediaType || strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, allowed[:len(allowed)-1]) {
+				return nil
+			}
+		}
+	}
+
+	return errors.UnsupportedMediaType("Unsupported content type").WithDetail("supported", []string(c))
+}
+
+// Requirements is the Describer interface implementation
+func (c ContentTypes) Requirements() []string {
+	requirements := make([]string, 0, len(c))
+	for _, contentType := range c {
+		requirements = append(requirements, "content-type:"+contentType)
+	}
+	return requirements
+}
@@ -0,0 +1,188 @@
+package compress
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Encoder returns a writer compressing into w. Closing it must flush the compressed data, but not close w
+type Encoder func(w io.Writer) io.WriteCloser
+
+// Gzip is the gzip Encoder, with the default compression level
+func Gzip(w io.Writer) io.WriteCloser {
+	return gzip.NewWriter(w)
+}
+
+// Deflate is the deflate Encoder, with the default compression level.
+// The deflate content coding is the zlib format, not raw deflate
+func Deflate(w io.Writer) io.WriteCloser {
+	return zlib.NewWriter(w)
+}
+
+// DefaultMinSize is the size under which responses aren't compressed by default
+const DefaultMinSize = 1024
+
+// DefaultSkipTypes are media types already compressed, not worth compressing again
+var DefaultSkipTypes = []string{
+	"image/*", "audio/*", "video/*", "font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2", "application/x-7z-compressed",
+	"application/x-rar-compressed", "application/zstd", "application/pdf", "application/octet-stream",
+}
+
+// Compressor is a middleware compressing the responses with the encoding negotiated with the Accept-Encoding header.
+// Strong ETags of encoded responses get the encoding appended, e.g. "abc-gzip", and it's removed from the
+// If-Match and If-None-Match headers of the requests.
+// Responses smaller than MinSize, with a media type in SkipTypes, already encoded or partial aren't compressed.
+// Flushing before MinSize is written compresses the response anyway, so streams are compressed as they go
+type Compressor struct {
+	// MinSize is the number of bytes buffered before deciding to compress. DefaultMinSize is used if zero or negative
+	MinSize int
+
+	// SkipTypes are the media types that aren't compressed. Types may end with a wildcard, e.g. "image/*"
+	SkipTypes []string
+
+	encodings []string
+	encoders  map[string]Encoder
+}
+
+// New = constructor for Compressor, with gzip and deflate
+func New() *Compressor {
+	c := &Compressor{MinSize: DefaultMinSize, SkipTypes: DefaultSkipTypes}
+	c.Register("deflate", Deflate)
+	c.Register("gzip", Gzip)
+	return c
+}
+
+// Register adds an encoder for the Content-Encoding name, e.g. "br" or "zstd".
+// Encoders registered later are preferred when the client accepts several equally
+func (c *Compressor) Register(name string, encoder Encoder) *Compressor {
+	name = strings.ToLower(name)
+	if c.encoders == nil {
+		c.encoders = make(map[string]Encoder)
+	}
+	if _, ok := c.encoders[name]; !ok {
+		c.encodings = append([]string{name}, c.encodings...)
+	}
+	c.encoders[name] = encoder
+	return c
+}
+
+// minSize returns MinSize, or DefaultMinSize if it isn't set
+func (c *Compressor) minSize() int {
+	if c.MinSize <= 0 {
+		return DefaultMinSize
+	}
+	return c.MinSize
+}
+
+// Middleware is the router.Middleware compressing the responses
+func (c *Compressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		c.untagConditions(rq)
+
+		encoding := c.negotiate(rq.Header.Get("Accept-Encoding"))
+		if encoding == "" || rq.Header.Get("Range") != "" {
+			next.ServeHTTP(w, rq)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, compressor: c, encoding: encoding}
+		defer cw.close()
+		next.ServeHTTP(cw, rq)
+	})
+}
+
+// accepted is an encoding of the Accept-Encoding header with its q value
+type accepted struct {
+	name string
+	q    float64
+}
+
+// untagConditions removes the encoding added to the ETags of encoded responses from the conditional headers,
+// so the inner handlers compare the ETags they set
+func (c *Compressor) untagConditions(rq *http.Request) {
+	for _, name := range []string{"If-Match", "If-None-Match"} {
+		value := rq.Header.Get(name)
+		if value == "" {
+			continue
+		}
+
+		for _, encoding := range c.encodings {
+			value = strings.ReplaceAll(value, "-"+encoding+`"`, `"`)
+		}
+		rq.Header.Set(name, value)
+	}
+}
+
+// negotiate returns the registered encoding with the highest q value in header, or an empty string if there is none
+func (c *Compressor) negotiate(header string) string {
+	var list []accepted
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			list = append(list, accepted{name, q})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].q > list[j].q })
+
+	for _, a := range list {
+		if a.q <= 0 {
+			break
+		}
+
+		if a.name == "*" {
+			// any encoding not explicitly refused
+			for _, encoding := range c.encodings {
+				if !refused(list, encoding) {
+					return encoding
+				}
+			}
+			continue
+		}
+
+		// equally accepted encodings go by the preference order
+		for _, encoding := range c.encodings {
+			for _, b := range list {
+				if b.q == a.q && b.name == encoding {
+					return encoding
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// refused tells if encoding is listed with q=0
+func refused(list []accepted, encoding string) bool {
+	for _, a := range list {
+		if a.name == encoding && a.q <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// skip tells if responses of the Content-Type aren't compressed
+func (c *Compressor) skip(contentType string) bool {
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	mediaType = strings.TrimSpace(mediaType)
+
+	for _, skipped := range c.SkipTypes {
+		if skipped == mediaType || strings.HasSuffix(skipped, "/*") && strings.HasPrefix(mediaType, skipped[:len(skipped)-1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package compress
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asvins/router"
+)
+
+func TestNegotiate(t *testing.T) {
+	c := New()
+	cases := map[string]string{
+		"":                          "",
+		"gzip":                      "gzip",
+		"deflate, gzip":             "gzip",
+		"gzip;q=0.5, deflate":       "deflate",
+		"br":                        "",
+		"*":                         "gzip",
+		"gzip;q=0, *":               "deflate",
+		"identity":                  "",
+		"GZIP;q=0.8, deflate;q=0.2": "gzip",
+	}
+	for header, expected := range cases {
+		if got := c.negotiate(header); got != expected {
+			t.Error("Encoding for", header, "should be", expected, " Got", got)
+		}
+	}
+
+	c.Register("br", Deflate)
+	if got := c.negotiate("gzip, br"); got != "br" {
+		t.Error("Encoders registered later should be preferred Got", got)
+	}
+
+	zero := (&Compressor{}).Register("gzip", Gzip)
+	if got := zero.negotiate("gzip"); got != "gzip" || zero.minSize() != DefaultMinSize {
+		t.Error("Zero value Compressor should accept encoders and use DefaultMinSize Got", got, zero.minSize())
+	}
+}
+
+func TestCompressor(t *testing.T) {
+	large := strings.Repeat(`{"id": 1, "name": "order"}`, 100)
+
+	r := router.NewRouter()
+	r.Use(New().Middleware)
+	r.AddRoute("/orders", router.GET, func(w http.ResponseWriter, rq *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "2600")
+		io.WriteString(w, large)
+	})
+	r.AddRoute("/small", router.GET, func(w http.ResponseWriter, rq *http.Request) {
+		io.WriteString(w, "ok")
+	})
+	r.AddRoute("/image", router.GET, func(w http.ResponseWriter, rq *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(make([]byte, 4096))
+	})
+	r.AddRoute("/tagged", router.GET, func(w http.ResponseWriter, rq *http.Request) {
+		w.Header().Set("ETag", `"c93eee2d"`)
+		if strings.HasSuffix(rq.Header.Get("If-None-Match"), `"c93eee2d"`) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		io.WriteString(w, large)
+	})
+	r.AddRoute("/empty", router.GET, func(w http.ResponseWriter, rq *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		rq := httptest.NewRequest("GET", path, nil)
+		rq.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, rq)
+		return w
+	}
+
+	w := get("/orders", "gzip")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") != "" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Error("Large responses should be compressed Got", w.Header())
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal("Body should be gzip Got", err)
+	}
+	if body, _ := io.ReadAll(reader); string(body) != large {
+		t.Error("Decompressed body should be the original Got", len(body), "bytes")
+	}
+
+	w = get("/orders", "deflate")
+	zreader, err := zlib.NewReader(w.Body)
+	if err != nil {
+		t.Fatal("Deflate body should be zlib Got", err)
+	}
+	if body, _ := io.ReadAll(zreader); w.Header().Get("Content-Encoding") != "deflate" || string(body) != large {
+		t.Error("Deflate should be used when it's the only one accepted Got", w.Header())
+	}
+
+	for path, expected := range map[string]string{"/small": "ok", "/image": string(make([]byte, 4096))} {
+		w = get(path, "gzip")
+		if w.Header().Get("Content-Encoding") != "" || w.Body.String() != expected || w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Error(path, "should not be compressed Got", w.Header())
+		}
+	}
+	if w = get("/small", "gzip"); w.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Error("Content-Type should be detected Got", w.Header().Get("Content-Type"))
+	}
+
+	if w = get("/tagged", "gzip"); w.Header().Get("ETag") != `"c93eee2d-gzip"` {
+		t.Error("Strong ETags should get the encoding Got", w.Header().Get("ETag"))
+	}
+	rq := httptest.NewRequest("GET", "/tagged", nil)
+	rq.Header.Set("Accept-Encoding", "gzip")
+	rq.Header.Set("If-None-Match", `"other", `+w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, rq)
+	if w.Code != http.StatusNotModified {
+		t.Error("The encoding should be removed from the conditions Got", w.Code)
+	}
+	if w.Header().Get("ETag") != `"c93eee2d-gzip"` {
+		t.Error("Not modified responses should keep the ETag of the compressed response Got", w.Header().Get("ETag"))
+	}
+	if w = get("/tagged", ""); w.Header().Get("ETag") != `"c93eee2d"` {
+		t.Error("ETags of identity responses should be kept Got", w.Header().Get("ETag"))
+	}
+
+	if w = get("/empty", "gzip"); w.Code != http.StatusNoContent || w.Header().Get("Content-Encoding") != "" {
+		t.Error("Responses without body should not be compressed Got", w.Code, w.Header())
+	}
+
+	if w = get("/orders", ""); w.Header().Get("Content-Encoding") != "" || w.Body.String() != large {
+		t.Error("Responses should not be compressed without Accept-Encoding Got", w.Header())
+	}
+
+	if w = get("/missing", "gzip"); w.Code != http.StatusNotFound || w.Header().Get("Content-Encoding") != "" {
+		t.Error("Small errors should not be compressed Got", w.Code, w.Header())
+	}
+}
+
+func TestCompressorFlush(t *testing.T) {
+	handler := New().Middleware(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: 1\n\n")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "data: 2\n\n")
+	}))
+
+	rq := httptest.NewRequest("GET", "/events", nil)
+	rq.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, rq)
+
+	if !w.Flushed || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Error("Flushed streams should be compressed Got", w.Header())
+	}
+	reader, _ := gzip.NewReader(w.Body)
+	if body, _ := io.ReadAll(reader); string(body) != "data: 1\n\ndata: 2\n\n" {
+		t.Error("Stream should have both events Got", string(body))
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (h hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	server, client := net.Pipe()
+	client.Close()
+	return server, bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server)), nil
+}
+
+func TestCompressorHijack(t *testing.T) {
+	handler := New().Middleware(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatal("Hijack should be passed to the wrapped writer Got", err)
+		}
+		conn.Close()
+	}))
+
+	rq := httptest.NewRequest("GET", "/ws", nil)
+	rq.Header.Set("Accept-Encoding", "gzip")
+	w := hijackRecorder{httptest.NewRecorder()}
+	handler.ServeHTTP(w, rq)
+
+	if w.Header().Get("Content-Encoding") != "" || w.Body.Len() != 0 {
+		t.Error("Nothing should be written after a hijack Got", w.Header())
+	}
+}
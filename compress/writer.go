@@ -0,0 +1,173 @@
+package compress
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// compressWriter buffers the start of the response until it knows if it's worth compressing,
+// then writes it through the encoder or as it is
+type compressWriter struct {
+	http.ResponseWriter
+	compressor *Compressor
+	encoding   string
+
+	status   int
+	buf      []byte
+	decided  bool
+	encoder  io.WriteCloser
+	hijacked bool
+}
+
+// WriteHeader records the status code, written once the compression is decided
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status != 0 {
+		return
+	}
+
+	// informational responses are written right away and the real one comes later
+	if code >= 100 && code < 200 {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	w.status = code
+	if code == http.StatusNotModified && w.Header().Get("Content-Encoding") == "" {
+		// the ETag must match the one of the compressed response the client validated
+		w.tagEncoding()
+	}
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+// Write buffers b until MinSize bytes were written, then compresses them if possible
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.compressor.minSize() {
+			return len(b), nil
+		}
+
+		w.decide(true)
+		if err := w.flushBuffer(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide writes the headers, compressing the response if wanted and possible
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	if compress && header.Get("Content-Encoding") == "" && header.Get("Content-Range") == "" &&
+		w.status != http.StatusPartialContent && !w.compressor.skip(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+
+		w.tagEncoding()
+		w.encoder = w.compressor.encoders[w.encoding](w.ResponseWriter)
+	}
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// tagEncoding adds the encoding to a strong ETag, as the encoded body isn't the one it was computed for
+func (w *compressWriter) tagEncoding() {
+	if tag := w.Header().Get("ETag"); strings.HasSuffix(tag, `"`) && !strings.HasPrefix(tag, "W/") {
+		w.Header().Set("ETag", strings.TrimSuffix(tag, `"`)+"-"+w.encoding+`"`)
+	}
+}
+
+// flushBuffer writes what was buffered before the decision
+func (w *compressWriter) flushBuffer() error {
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close writes a response smaller than MinSize as it is, or ends the compressed stream
+func (w *compressWriter) close() {
+	if w.hijacked {
+		return
+	}
+
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			// nothing written, let the server write its default response
+			return
+		}
+		w.decide(false)
+		w.flushBuffer()
+	}
+
+	if w.encoder != nil {
+		w.encoder.Close()
+	}
+}
+
+// Flush - needed to implement http.Flusher. Flushing before MinSize is written compresses the response anyway
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+		w.flushBuffer()
+	}
+
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack - needed to implement http.Hijacker. The connection is handed as it is, without compression
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped writer, used by http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
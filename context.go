@@ -0,0 +1,71 @@
+package router
+
+import (
+	"io"
+	"net/http"
+)
+
+// contextKey is the type of the keys this package stores in the request context
+type contextKey int
+
+const (
+	rendererKey contextKey = iota
+	stateKey
+	requestIDKey
+)
+
+// requestState is what the router learns about a request while handling it.
+// It's stored as a pointer so middleware see what was set by the inner handlers
+type requestState struct {
+	pattern   string
+	requestID string
+	values    map[interface{}]interface{}
+
+	// body is the request body before any limit and limited the body that limits it,
+	// so a more specific limit replaces the previous one
+	body    io.ReadCloser
+	limited io.ReadCloser
+
+	// bodyLimit is the body limit in effect and bodyTooLarge tells if reading the body exceeded it
+	bodyLimit    int64
+	bodyTooLarge bool
+}
+
+// getState returns the state of rq, or nil if it isn't being handled by a router
+func getState(rq *http.Request) *requestState {
+	state, _ := rq.Context().Value(stateKey).(*requestState)
+	return state
+}
+
+// Pattern returns the pattern of the route that matched rq, e.g. /user/:uid, or an empty string if no route matched
+func Pattern(rq *http.Request) string {
+	if state := getState(rq); state != nil {
+		return state.pattern
+	}
+	return ""
+}
+
+// SetValue stores a value for the rest of the handling of rq. Unlike context.WithValue it doesn't need a new request,
+// so interceptors can pass values to the next interceptors and the handler, e.g. the authenticated user.
+// Keys should be of an unexported type, as context keys. It does nothing if rq isn't being handled by a router
+func SetValue(rq *http.Request, key, value interface{}) {
+	state := getState(rq)
+	if state == nil {
+		return
+	}
+
+	if state.values == nil {
+		state.values = make(map[interface{}]interface{})
+	}
+	state.values[key] = value
+}
+
+// Value returns the value stored with SetValue for key, or the one of the request context if there is none
+func Value(rq *http.Request, key interface{}) interface{} {
+	if state := getState(rq); state != nil {
+		if value, ok := state.values[key]; ok {
+			return value
+		}
+	}
+	return rq.Context().Value(key)
+}
@@ -0,0 +1,188 @@
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asvins/router"
+)
+
+// Options configures which cross-origin requests are allowed
+type Options struct {
+	// AllowedOrigins are the origins allowed, e.g. https://example.com.
+	// "*" allows any origin and a '*' inside an origin matches any subdomain, e.g. https://*.example.com
+	AllowedOrigins []string
+
+	// AllowOriginFunc allows the origins it returns true for, in addition to AllowedOrigins
+	AllowOriginFunc func(origin string) bool
+
+	// AllowedMethods limits the methods allowed in preflights.
+	// If empty, all the methods the router has for the path are allowed
+	AllowedMethods []string
+
+	// AllowedHeaders are the request headers allowed in preflights, case insensitive.
+	// If empty, the headers requested in the preflight are allowed
+	AllowedHeaders []string
+
+	// ExposedHeaders are the response headers the browser makes available to the client
+	ExposedHeaders []string
+
+	// AllowCredentials allows cookies and Authorization headers, echoing the allowed request origin.
+	// It can't be used with the "*" origin, as any website could then make authenticated requests
+	AllowCredentials bool
+
+	// MaxAge is how long the browser can cache a preflight. Not sent if zero
+	MaxAge time.Duration
+}
+
+// CORS adds the CORS headers to the responses and answers the preflight requests.
+// It's a router.Middleware, so preflights are answered even though the router has no OPTIONS routes:
+//
+//	r.Use(cors.New(r, cors.Options{AllowedOrigins: []string{"https://*.example.com"}}).Middleware)
+type CORS struct {
+	router  *router.Router
+	options Options
+}
+
+// New = constructor for CORS. The router is used to know which methods exist for a path.
+// It panics if credentials are allowed for the "*" origin
+func New(r *router.Router, options Options) *CORS {
+	if options.AllowCredentials && contains(options.AllowedOrigins, "*") {
+		panic(`cors: AllowCredentials can't be used with the "*" origin, list the allowed origins instead`)
+	}
+	return &CORS{router: r, options: options}
+}
+
+// Middleware - needed to be used as router.Middleware
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		origin := rq.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+
+		if rq.Method == http.MethodOptions && rq.Header.Get("Access-Control-Request-Method") != "" {
+			if c.preflight(w, rq, origin) {
+				return
+			}
+		} else if origin != "" && c.allowedOrigin(origin) {
+			c.setOrigin(w, origin)
+			if len(c.options.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.options.ExposedHeaders, ", "))
+			}
+		}
+
+		next.ServeHTTP(w, rq)
+	})
+}
+
+// preflight answers the preflight request. Returns false if the router has no route for the path,
+// so the request goes on and is not found
+func (c *CORS) preflight(w http.ResponseWriter, rq *http.Request, origin string) bool {
+	methods := c.methods(rq.URL.Path)
+	if len(methods) == 0 {
+		return false
+	}
+
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	// without the allow headers the browser blocks the actual request
+	requestedMethod := rq.Header.Get("Access-Control-Request-Method")
+	requestedHeaders := rq.Header.Get("Access-Control-Request-Headers")
+	if origin == "" || !c.allowedOrigin(origin) || !contains(methods, requestedMethod) || !c.allowedHeaders(requestedHeaders) {
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+
+	c.setOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(c.options.AllowedHeaders) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.options.AllowedHeaders, ", "))
+	} else if requestedHeaders != "" {
+		w.Header().Set("Access-Control-Allow-Headers", requestedHeaders)
+	}
+	if c.options.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.options.MaxAge.Seconds())))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// setOrigin sets the allowed origin and credentials headers
+func (c *CORS) setOrigin(w http.ResponseWriter, origin string) {
+	if contains(c.options.AllowedOrigins, "*") {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if c.options.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// methods returns the methods of the router for path allowed by the options
+func (c *CORS) methods(path string) []string {
+	methods := c.router.Methods(path)
+	if len(c.options.AllowedMethods) == 0 {
+		return methods
+	}
+
+	var allowed []string
+	for _, method := range methods {
+		if contains(c.options.AllowedMethods, method) {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// allowedOrigin reports whether origin is allowed by the options
+func (c *CORS) allowedOrigin(origin string) bool {
+	for _, allowed := range c.options.AllowedOrigins {
+		if allowed == "*" || matchOrigin(allowed, origin) {
+			return true
+		}
+	}
+	return c.options.AllowOriginFunc != nil && c.options.AllowOriginFunc(origin)
+}
+
+// allowedHeaders reports whether all the headers of the comma separated list are allowed
+func (c *CORS) allowedHeaders(requested string) bool {
+	if len(c.options.AllowedHeaders) == 0 || requested == "" {
+		return true
+	}
+
+	for _, header := range strings.Split(requested, ",") {
+		if !contains(c.options.AllowedHeaders, strings.TrimSpace(header)) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchOrigin reports whether origin matches the pattern, where a '*' matches any subdomain
+func matchOrigin(pattern, origin string) bool {
+	pattern, origin = strings.ToLower(pattern), strings.ToLower(origin)
+	i := strings.Index(pattern, "*")
+	if i < 0 {
+		return pattern == origin
+	}
+
+	prefix, suffix := pattern[:i], pattern[i+1:]
+	return len(origin) > len(prefix)+len(suffix) &&
+		strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) &&
+		!strings.Contains(origin[len(prefix):len(origin)-len(suffix)], "/")
+}
+
+// contains reports whether values has value, case insensitive
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asvins/router"
+)
+
+func TestCORS(t *testing.T) {
+	r := router.NewRouter()
+	r.AddRoute("/orders", router.GET, func(w http.ResponseWriter, rq *http.Request) {})
+	r.AddRoute("/orders", router.POST, func(w http.ResponseWriter, rq *http.Request) {})
+	r.Use(New(r, Options{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowOriginFunc:  func(origin string) bool { return origin == "http://localhost:3000" },
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}).Middleware)
+
+	cases := []struct {
+		method        string
+		path          string
+		origin        string
+		requestMethod string
+		code          int
+		allowOrigin   string
+		allowMethods  string
+	}{
+		{http.MethodOptions, "/orders", "https://app.example.com", "POST", http.StatusNoContent, "https://app.example.com", "GET, POST"},
+		{http.MethodOptions, "/orders", "http://localhost:3000", "GET", http.StatusNoContent, "http://localhost:3000", "GET, POST"},
+		{http.MethodOptions, "/orders", "https://evil.com", "POST", http.StatusNoContent, "", ""},
+		{http.MethodOptions, "/orders", "https://app.example.com", "DELETE", http.StatusNoContent, "", ""},
+		{http.MethodOptions, "/missing", "https://app.example.com", "GET", http.StatusNotFound, "", ""},
+		{router.GET, "/orders", "https://app.example.com", "", http.StatusOK, "https://app.example.com", ""},
+		{router.GET, "/orders", "https://example.com.evil.com", "", http.StatusOK, "", ""},
+	}
+
+	for _, c := range cases {
+		rq := httptest.NewRequest(c.method, c.path, nil)
+		rq.Header.Set("Origin", c.origin)
+		if c.requestMethod != "" {
+			rq.Header.Set("Access-Control-Request-Method", c.requestMethod)
+			rq.Header.Set("Access-Control-Request-Headers", "content-type")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, rq)
+
+		if w.Code != c.code {
+			t.Error(c.method, c.path, c.origin, "Status Code should be", c.code, " Got", w.Code)
+		}
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != c.allowOrigin {
+			t.Error(c.method, c.path, c.origin, "Access-Control-Allow-Origin should be", c.allowOrigin, " Got", got)
+		}
+
+		if got := w.Header().Get("Access-Control-Allow-Methods"); got != c.allowMethods {
+			t.Error(c.method, c.path, c.origin, "Access-Control-Allow-Methods should be", c.allowMethods, " Got", got)
+		}
+
+		if w.Header().Get("Vary") != "Origin" {
+			t.Error("Vary should begin with Origin. Got", w.Header()["Vary"])
+		}
+	}
+
+	rq := httptest.NewRequest(http.MethodOptions, "/orders", nil)
+	rq.Header.Set("Origin", "https://app.example.com")
+	rq.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, rq)
+	if w.Header().Get("Access-Control-Max-Age") != "600" || w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("Preflight should have max age and credentials. Got", w.Header())
+	}
+}
+
+func TestCredentialsWithAnyOrigin(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("New should panic when credentials are allowed for any origin")
+		}
+	}()
+	New(router.NewRouter(), Options{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+}
@@ -0,0 +1,67 @@
+package router
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+
+	"github.com/asvins/router/errors"
+)
+
+// DebugInfo is added to rendered errors when the router is in debug mode
+type DebugInfo struct {
+	Origin string   `json:"origin" xml:"origin"`
+	Causes []string `json:"causes,omitempty" xml:"causes>cause,omitempty"`
+	Stack  []string `json:"stack,omitempty" xml:"stack>frame,omitempty"`
+}
+
+// debugInfo returns the debug information of err, or nil if debug mode is disabled
+func (r *Router) debugInfo(err errors.Http, origin string) *DebugInfo {
+	if !debugBuild || !r.Debug {
+		return nil
+	}
+
+	info := &DebugInfo{Origin: origin}
+
+	// cause chain, starting on the first error wrapped by err
+	if e, ok := err.(error); ok {
+		for cause := unwrap(e); cause != nil; cause = unwrap(cause) {
+			info.Causes = append(info.Causes, cause.Error())
+		}
+	}
+
+	if stacked, ok := err.(errors.Stacked); ok {
+		info.Stack = stacked.StackTrace()
+	}
+
+	return info
+}
+
+// unwrap returns the error wrapped by err, or nil if there is none
+func unwrap(err error) error {
+	if u, ok := err.(interface{ Unwrap() error }); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
+// funcName returns the name of the function f
+func funcName(f interface{}) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+
+	if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
+
+// interceptorName returns the type name of the interceptor
+func interceptorName(interceptor Interceptor) string {
+	if interceptor == nil {
+		return ""
+	}
+	return fmt.Sprintf("%T", interceptor)
+}
@@ -0,0 +1,6 @@
+//go:build !production
+
+package router
+
+// debugBuild enables Router.Debug. Builds with the production tag strip the debug information entirely
+const debugBuild = true
@@ -0,0 +1,6 @@
+//go:build production
+
+package router
+
+// debugBuild disables Router.Debug in production builds
+const debugBuild = false
@@ -32,6 +32,21 @@ func (e BadRequestStruct) Code() int {
 	return http.StatusBadRequest
 }
 
+// Error - needed to implement error interface
+func (e BadRequestStruct) Error() string {
+	return errorString(e)
+}
+
+// Is reports whether target has the same status code. Used by the standard errors.Is
+func (e BadRequestStruct) Is(target error) bool {
+	return hasCode(target, e.Code())
+}
+
+// Unwrap - there is no underlying cause
+func (e BadRequestStruct) Unwrap() error {
+	return nil
+}
+
 /*
 *	HTTP status Unauthorized
  */
@@ -55,6 +70,21 @@ func (e UnauthorizedStruct) Code() int {
 	return http.StatusUnauthorized
 }
 
+// Error - needed to implement error interface
+func (e UnauthorizedStruct) Error() string {
+	return errorString(e)
+}
+
+// Is reports whether target has the same status code. Used by the standard errors.Is
+func (e UnauthorizedStruct) Is(target error) bool {
+	return hasCode(target, e.Code())
+}
+
+// Unwrap - there is no underlying cause
+func (e UnauthorizedStruct) Unwrap() error {
+	return nil
+}
+
 /*
 *	HTTP status NotFound
  */
@@ -78,6 +108,59 @@ func (e NotFoundStruct) Code() int {
 	return http.StatusNotFound
 }
 
+// Error - needed to implement error interface
+func (e NotFoundStruct) Error() string {
+	return errorString(e)
+}
+
+// Is reports whether target has the same status code. Used by the standard errors.Is
+func (e NotFoundStruct) Is(target error) bool {
+	return hasCode(target, e.Code())
+}
+
+// Unwrap - there is no underlying cause
+func (e NotFoundStruct) Unwrap() error {
+	return nil
+}
+
+/*
+*	HTTP status MethodNotAllowed
+ */
+// MethodNotAllowedStruct http error
+type MethodNotAllowedStruct struct {
+	Msg string `json:"message"`
+}
+
+// MethodNotAllowed returns a newly allocated MethodNotAllowedStruct
+func MethodNotAllowed(message string) MethodNotAllowedStruct {
+	return MethodNotAllowedStruct{message}
+}
+
+// Message - needed to implement HttpErrors interface
+func (e MethodNotAllowedStruct) Message() string {
+	return e.Msg
+}
+
+// Code - needed to implement Http interface
+func (e MethodNotAllowedStruct) Code() int {
+	return http.StatusMethodNotAllowed
+}
+
+// Error - needed to implement error interface
+func (e MethodNotAllowedStruct) Error() string {
+	return errorString(e)
+}
+
+// Is reports whether target has the same status code. Used by the standard errors.Is
+func (e MethodNotAllowedStruct) Is(target error) bool {
+	return hasCode(target, e.Code())
+}
+
+// Unwrap - there is no underlying cause
+func (e MethodNotAllowedStruct) Unwrap() error {
+	return nil
+}
+
 /*
 *	HTTP status InternalServerError
  */
@@ -100,3 +183,18 @@ func (e InternalServerErrorStruct) Message() string {
 func (e InternalServerErrorStruct) Code() int {
 	return http.StatusInternalServerError
 }
+
+// Error - needed to implement error interface
+func (e InternalServerErrorStruct) Error() string {
+	return errorString(e)
+}
+
+// Is reports whether target has the same status code. Used by the standard errors.Is
+func (e InternalServerErrorStruct) Is(target error) bool {
+	return hasCode(target, e.Code())
+}
+
+// Unwrap - there is no underlying cause
+func (e InternalServerErrorStruct) Unwrap() error {
+	return nil
+}
@@ -0,0 +1,204 @@
+package errors
+
+import (
+	"net/http"
+)
+
+// Coded is implemented by errors carrying a stable application code, e.g. USER_NOT_FOUND
+type Coded interface {
+	ErrorCode() string
+}
+
+// Detailed is implemented by errors carrying machine-readable details
+type Detailed interface {
+	ErrorDetails() map[string]interface{}
+}
+
+// Localizable is implemented by errors whose message can be replaced, e.g. by its translation.
+// The errors of this package are localizable without it
+type Localizable interface {
+	WithMessage(msg string) Http
+}
+
+// Headered is implemented by errors carrying headers that must be set on the response,
+// e.g. Retry-After or WWW-Authenticate
+type Headered interface {
+	ErrorHeader() http.Header
+}
+
+// Extend converts err into a StatusStruct with the same code and message so metadata can be added to it.
+// The cause, code, details and headers are kept if err is a StatusStruct
+func Extend(err Http) StatusStruct {
+	if e, ok := err.(StatusStruct); ok {
+		return e
+	}
+	return New(err.Code(), err.Message())
+}
+
+// WithCode returns a copy of the error with the given application code
+func (e StatusStruct) WithCode(code string) StatusStruct {
+	e.AppCode = code
+	return e
+}
+
+// WithDetail returns a copy of the error with the detail key set to value
+func (e StatusStruct) WithDetail(key string, value interface{}) StatusStruct {
+	details := make(map[string]interface{}, len(e.Details)+1)
+	for k, v := range e.Details {
+		details[k] = v
+	}
+	details[key] = value
+	e.Details = details
+	return e
+}
+
+// WithHeader returns a copy of the error with the response header key set to value
+func (e StatusStruct) WithHeader(key, value string) StatusStruct {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(key, value)
+	e.Header = header
+	return e
+}
+
+// ErrorCode - needed to implement Coded interface
+func (e StatusStruct) ErrorCode() string {
+	return e.AppCode
+}
+
+// ErrorDetails - needed to implement Detailed interface
+func (e StatusStruct) ErrorDetails() map[string]interface{} {
+	return e.Details
+}
+
+// ErrorHeader - needed to implement Headered interface
+func (e StatusStruct) ErrorHeader() http.Header {
+	return e.Header
+}
+
+// WithCode returns the error as a StatusStruct with the given application code
+func (e BadRequestStruct) WithCode(code string) StatusStruct {
+	return Extend(e).WithCode(code)
+}
+
+// WithDetail returns the error as a StatusStruct with the detail key set to value
+func (e BadRequestStruct) WithDetail(key string, value interface{}) StatusStruct {
+	return Extend(e).WithDetail(key, value)
+}
+
+// WithHeader returns the error as a StatusStruct with the response header key set to value
+func (e BadRequestStruct) WithHeader(key, value string) StatusStruct {
+	return Extend(e).WithHeader(key, value)
+}
+
+// WithCode returns the error as a StatusStruct with the given application code
+func (e UnauthorizedStruct) WithCode(code string) StatusStruct {
+	return Extend(e).WithCode(code)
+}
+
+// WithDetail returns the error as a StatusStruct with the detail key set to value
+func (e UnauthorizedStruct) WithDetail(key string, value interface{}) StatusStruct {
+	return Extend(e).WithDetail(key, value)
+}
+
+// WithHeader returns the error as a StatusStruct with the response header key set to value
+func (e UnauthorizedStruct) WithHeader(key, value string) StatusStruct {
+	return Extend(e).WithHeader(key, value)
+}
+
+// WithCode returns the error as a StatusStruct with the given application code
+func (e NotFoundStruct) WithCode(code string) StatusStruct {
+	return Extend(e).WithCode(code)
+}
+
+// WithDetail returns the error as a StatusStruct with the detail key set to value
+func (e NotFoundStruct) WithDetail(key string, value interface{}) StatusStruct {
+	return Extend(e).WithDetail(key, value)
+}
+
+// WithHeader returns the error as a StatusStruct with the response header key set to value
+func (e NotFoundStruct) WithHeader(key, value string) StatusStruct {
+	return Extend(e).WithHeader(key, value)
+}
+
+// WithCode returns the error as a StatusStruct with the given application code
+func (e MethodNotAllowedStruct) WithCode(code string) StatusStruct {
+	return Extend(e).WithCode(code)
+}
+
+// WithDetail returns the error as a StatusStruct with the detail key set to value
+func (e MethodNotAllowedStruct) WithDetail(key string, value interface{}) StatusStruct {
+	return Extend(e).WithDetail(key, value)
+}
+
+// WithHeader returns the error as a StatusStruct with the response header key set to value
+func (e MethodNotAllowedStruct) WithHeader(key, value string) StatusStruct {
+	return Extend(e).WithHeader(key, value)
+}
+
+// WithCode returns the error as a StatusStruct with the given application code
+func (e InternalServerErrorStruct) WithCode(code string) StatusStruct {
+	return Extend(e).WithCode(code)
+}
+
+// WithDetail returns the error as a StatusStruct with the detail key set to value
+func (e InternalServerErrorStruct) WithDetail(key string, value interface{}) StatusStruct {
+	return Extend(e).WithDetail(key, value)
+}
+
+// WithHeader returns the error as a StatusStruct with the response header key set to value
+func (e InternalServerErrorStruct) WithHeader(key, value string) StatusStruct {
+	return Extend(e).WithHeader(key, value)
+}
+
+// WithMessage returns a copy of err with the message replaced by msg, keeping its type.
+// Errors of other types are returned unchanged, unless they implement Localizable
+func WithMessage(err Http, msg string) Http {
+	localized, _ := withMessage(err, msg)
+	return localized
+}
+
+// CanLocalize reports whether the message of err can be replaced with WithMessage
+func CanLocalize(err Http) bool {
+	_, ok := withMessage(err, "")
+	return ok
+}
+
+// withMessage replaces the message of err, reporting whether its type allowed it
+func withMessage(err Http, msg string) (Http, bool) {
+	switch e := err.(type) {
+	case StatusStruct:
+		e.Msg = msg
+		return e, true
+	case BadRequestStruct:
+		e.Msg = msg
+		return e, true
+	case UnauthorizedStruct:
+		e.Msg = msg
+		return e, true
+	case NotFoundStruct:
+		e.Msg = msg
+		return e, true
+	case MethodNotAllowedStruct:
+		e.Msg = msg
+		return e, true
+	case InternalServerErrorStruct:
+		e.Msg = msg
+		return e, true
+	case ValidationStruct:
+		e.Msg = msg
+		return e, true
+	case *ValidationStruct:
+		copied := *e
+		copied.Msg = msg
+		return &copied, true
+	case ProblemStruct:
+		e.Detail = msg
+		return e, true
+	case Localizable:
+		return e.WithMessage(msg), true
+	}
+	return err, false
+}
@@ -0,0 +1,132 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+/*
+*	RFC 7807 problem details
+ */
+// ProblemStruct http error rendered as an application/problem+json document
+type ProblemStruct struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// Problem returns a newly allocated ProblemStruct with the given status and the default type and title
+func Problem(status int) ProblemStruct {
+	return ProblemStruct{Type: "about:blank", Title: http.StatusText(status), Status: status}
+}
+
+// ToProblem converts err into a ProblemStruct, using its message as detail.
+// Field errors, application code and details, if any, become the fields, code and details members
+func ToProblem(err Http) ProblemStruct {
+	if p, ok := err.(ProblemStruct); ok {
+		return p
+	}
+
+	p := Problem(err.Code()).WithDetail(err.Message())
+	if fields := Fields(err); fields != nil {
+		p = p.With("fields", fields)
+	}
+	if coded, ok := err.(Coded); ok && coded.ErrorCode() != "" {
+		p = p.With("code", coded.ErrorCode())
+	}
+	if detailed, ok := err.(Detailed); ok && len(detailed.ErrorDetails()) > 0 {
+		p = p.With("details", detailed.ErrorDetails())
+	}
+	return p
+}
+
+// WithType returns a copy of the problem with the given type URI
+func (e ProblemStruct) WithType(uri string) ProblemStruct {
+	e.Type = uri
+	return e
+}
+
+// WithTitle returns a copy of the problem with the given title
+func (e ProblemStruct) WithTitle(title string) ProblemStruct {
+	e.Title = title
+	return e
+}
+
+// WithDetail returns a copy of the problem with the given detail
+func (e ProblemStruct) WithDetail(detail string) ProblemStruct {
+	e.Detail = detail
+	return e
+}
+
+// WithInstance returns a copy of the problem with the given instance URI
+func (e ProblemStruct) WithInstance(uri string) ProblemStruct {
+	e.Instance = uri
+	return e
+}
+
+// With returns a copy of the problem with the extension member key set to value
+func (e ProblemStruct) With(key string, value interface{}) ProblemStruct {
+	extensions := make(map[string]interface{}, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		extensions[k] = v
+	}
+	extensions[key] = value
+	e.Extensions = extensions
+	return e
+}
+
+// Message - needed to implement HttpErrors interface
+func (e ProblemStruct) Message() string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+	return e.Title
+}
+
+// Code - needed to implement Http interface
+func (e ProblemStruct) Code() int {
+	return e.Status
+}
+
+// Error - needed to implement error interface
+func (e ProblemStruct) Error() string {
+	return errorString(e)
+}
+
+// Is reports whether target has the same status code. Used by the standard errors.Is
+func (e ProblemStruct) Is(target error) bool {
+	return hasCode(target, e.Status)
+}
+
+// Unwrap - there is no underlying cause
+func (e ProblemStruct) Unwrap() error {
+	return nil
+}
+
+// MarshalJSON writes the standard members together with the extension members
+func (e ProblemStruct) MarshalJSON() ([]byte, error) {
+	doc := make(map[string]interface{}, len(e.Extensions)+5)
+	for k, v := range e.Extensions {
+		doc[k] = v
+	}
+
+	doc["type"] = e.Type
+	if doc["type"] == "" {
+		doc["type"] = "about:blank"
+	}
+	doc["status"] = e.Status
+	if e.Title != "" {
+		doc["title"] = e.Title
+	}
+	if e.Detail != "" {
+		doc["detail"] = e.Detail
+	}
+	if e.Instance != "" {
+		doc["instance"] = e.Instance
+	}
+
+	return json.Marshal(doc)
+}
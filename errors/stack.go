@@ -0,0 +1,41 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Stacked is implemented by errors carrying the stack trace of where they were created
+type Stacked interface {
+	StackTrace() []string
+}
+
+// callers returns the program counters of the stack, starting on the caller of the function calling it.
+// Builds with the production tag capture no stack, as it's only rendered in debug mode
+func callers() []uintptr {
+	if !captureStacks {
+		return nil
+	}
+
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	return pcs[:n]
+}
+
+// StackTrace - needed to implement Stacked interface.
+// Frames of this package are skipped, so it starts on the function that created the error
+func (e StatusStruct) StackTrace() []string {
+	var trace []string
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/asvins/router/errors.") {
+			trace = append(trace, fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return trace
+}
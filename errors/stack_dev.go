@@ -0,0 +1,6 @@
+//go:build !production
+
+package errors
+
+// captureStacks makes errors capture the stack of where they were created, for the router debug mode
+const captureStacks = true
@@ -0,0 +1,6 @@
+//go:build production
+
+package errors
+
+// captureStacks is disabled in production builds, where the router debug mode is stripped
+const captureStacks = false
@@ -0,0 +1,285 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+)
+
+/*
+*	Any HTTP status
+ */
+// StatusStruct http error with any status code.
+// Cause is never rendered on the response, only returned by Error() and Unwrap() for logging.
+// AppCode and Details are rendered when set and Header is applied on the response before rendering
+type StatusStruct struct {
+	Status  int                    `json:"-"`
+	Msg     string                 `json:"message"`
+	AppCode string                 `json:"code,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	Header  http.Header            `json:"-"`
+	Cause   error                  `json:"-"`
+
+	stack []uintptr
+}
+
+// New returns a newly allocated StatusStruct with the given status code
+func New(code int, msg string) StatusStruct {
+	return StatusStruct{Status: code, Msg: msg, stack: callers()}
+}
+
+// Wrap returns a newly allocated StatusStruct with the given status code caused by cause.
+// The client only sees msg, cause is kept for logging
+func Wrap(cause error, code int, msg string) StatusStruct {
+	return StatusStruct{Status: code, Msg: msg, Cause: cause, stack: callers()}
+}
+
+// From converts err into an Http error.
+// If err is or wraps an Http error it is returned, otherwise err is wrapped into an InternalServerError
+func From(err error) Http {
+	if err == nil {
+		return nil
+	}
+
+	if httpErr, ok := err.(Http); ok {
+		return httpErr
+	}
+
+	var httpErr Http
+	if stderrors.As(err, &httpErr) {
+		return httpErr
+	}
+
+	// body read over the limit of http.MaxBytesReader
+	var maxBytesErr *http.MaxBytesError
+	if stderrors.As(err, &maxBytesErr) {
+		return Wrap(err, http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+	}
+
+	return Wrap(err, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+}
+
+// Message - needed to implement HttpErrors interface
+func (e StatusStruct) Message() string {
+	return e.Msg
+}
+
+// Code - needed to implement Http interface
+func (e StatusStruct) Code() int {
+	return e.Status
+}
+
+// Error - needed to implement error interface. Includes the cause, if any
+func (e StatusStruct) Error() string {
+	if e.Cause != nil {
+		return errorString(e) + ": " + e.Cause.Error()
+	}
+	return errorString(e)
+}
+
+// Unwrap returns the cause of the error
+func (e StatusStruct) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target has the same status code. Used by the standard errors.Is
+func (e StatusStruct) Is(target error) bool {
+	return hasCode(target, e.Status)
+}
+
+// PaymentRequired returns a newly allocated StatusStruct with status PaymentRequired
+func PaymentRequired(msg string) StatusStruct {
+	return New(http.StatusPaymentRequired, msg)
+}
+
+// Forbidden returns a newly allocated StatusStruct with status Forbidden
+func Forbidden(msg string) StatusStruct {
+	return New(http.StatusForbidden, msg)
+}
+
+// NotAcceptable returns a newly allocated StatusStruct with status NotAcceptable
+func NotAcceptable(msg string) StatusStruct {
+	return New(http.StatusNotAcceptable, msg)
+}
+
+// ProxyAuthRequired returns a newly allocated StatusStruct with status ProxyAuthRequired
+func ProxyAuthRequired(msg string) StatusStruct {
+	return New(http.StatusProxyAuthRequired, msg)
+}
+
+// RequestTimeout returns a newly allocated StatusStruct with status RequestTimeout
+func RequestTimeout(msg string) StatusStruct {
+	return New(http.StatusRequestTimeout, msg)
+}
+
+// Conflict returns a newly allocated StatusStruct with status Conflict
+func Conflict(msg string) StatusStruct {
+	return New(http.StatusConflict, msg)
+}
+
+// Gone returns a newly allocated StatusStruct with status Gone
+func Gone(msg string) StatusStruct {
+	return New(http.StatusGone, msg)
+}
+
+// LengthRequired returns a newly allocated StatusStruct with status LengthRequired
+func LengthRequired(msg string) StatusStruct {
+	return New(http.StatusLengthRequired, msg)
+}
+
+// PreconditionFailed returns a newly allocated StatusStruct with status PreconditionFailed
+func PreconditionFailed(msg string) StatusStruct {
+	return New(http.StatusPreconditionFailed, msg)
+}
+
+// RequestEntityTooLarge returns a newly allocated StatusStruct with status RequestEntityTooLarge
+func RequestEntityTooLarge(msg string) StatusStruct {
+	return New(http.StatusRequestEntityTooLarge, msg)
+}
+
+// RequestURITooLong returns a newly allocated StatusStruct with status RequestURITooLong
+func RequestURITooLong(msg string) StatusStruct {
+	return New(http.StatusRequestURITooLong, msg)
+}
+
+// UnsupportedMediaType returns a newly allocated StatusStruct with status UnsupportedMediaType
+func UnsupportedMediaType(msg string) StatusStruct {
+	return New(http.StatusUnsupportedMediaType, msg)
+}
+
+// RequestedRangeNotSatisfiable returns a newly allocated StatusStruct with status RequestedRangeNotSatisfiable
+func RequestedRangeNotSatisfiable(msg string) StatusStruct {
+	return New(http.StatusRequestedRangeNotSatisfiable, msg)
+}
+
+// ExpectationFailed returns a newly allocated StatusStruct with status ExpectationFailed
+func ExpectationFailed(msg string) StatusStruct {
+	return New(http.StatusExpectationFailed, msg)
+}
+
+// Teapot returns a newly allocated StatusStruct with status Teapot
+func Teapot(msg string) StatusStruct {
+	return New(http.StatusTeapot, msg)
+}
+
+// MisdirectedRequest returns a newly allocated StatusStruct with status MisdirectedRequest
+func MisdirectedRequest(msg string) StatusStruct {
+	return New(http.StatusMisdirectedRequest, msg)
+}
+
+// UnprocessableEntity returns a newly allocated StatusStruct with status UnprocessableEntity
+func UnprocessableEntity(msg string) StatusStruct {
+	return New(http.StatusUnprocessableEntity, msg)
+}
+
+// Locked returns a newly allocated StatusStruct with status Locked
+func Locked(msg string) StatusStruct {
+	return New(http.StatusLocked, msg)
+}
+
+// FailedDependency returns a newly allocated StatusStruct with status FailedDependency
+func FailedDependency(msg string) StatusStruct {
+	return New(http.StatusFailedDependency, msg)
+}
+
+// TooEarly returns a newly allocated StatusStruct with status TooEarly
+func TooEarly(msg string) StatusStruct {
+	return New(http.StatusTooEarly, msg)
+}
+
+// UpgradeRequired returns a newly allocated StatusStruct with status UpgradeRequired
+func UpgradeRequired(msg string) StatusStruct {
+	return New(http.StatusUpgradeRequired, msg)
+}
+
+// PreconditionRequired returns a newly allocated StatusStruct with status PreconditionRequired
+func PreconditionRequired(msg string) StatusStruct {
+	return New(http.StatusPreconditionRequired, msg)
+}
+
+// TooManyRequests returns a newly allocated StatusStruct with status TooManyRequests
+func TooManyRequests(msg string) StatusStruct {
+	return New(http.StatusTooManyRequests, msg)
+}
+
+// RequestHeaderFieldsTooLarge returns a newly allocated StatusStruct with status RequestHeaderFieldsTooLarge
+func RequestHeaderFieldsTooLarge(msg string) StatusStruct {
+	return New(http.StatusRequestHeaderFieldsTooLarge, msg)
+}
+
+// UnavailableForLegalReasons returns a newly allocated StatusStruct with status UnavailableForLegalReasons
+func UnavailableForLegalReasons(msg string) StatusStruct {
+	return New(http.StatusUnavailableForLegalReasons, msg)
+}
+
+// NotImplemented returns a newly allocated StatusStruct with status NotImplemented
+func NotImplemented(msg string) StatusStruct {
+	return New(http.StatusNotImplemented, msg)
+}
+
+// BadGateway returns a newly allocated StatusStruct with status BadGateway
+func BadGateway(msg string) StatusStruct {
+	return New(http.StatusBadGateway, msg)
+}
+
+// ServiceUnavailable returns a newly allocated StatusStruct with status ServiceUnavailable
+func ServiceUnavailable(msg string) StatusStruct {
+	return New(http.StatusServiceUnavailable, msg)
+}
+
+// GatewayTimeout returns a newly allocated StatusStruct with status GatewayTimeout
+func GatewayTimeout(msg string) StatusStruct {
+	return New(http.StatusGatewayTimeout, msg)
+}
+
+// HTTPVersionNotSupported returns a newly allocated StatusStruct with status HTTPVersionNotSupported
+func HTTPVersionNotSupported(msg string) StatusStruct {
+	return New(http.StatusHTTPVersionNotSupported, msg)
+}
+
+// VariantAlsoNegotiates returns a newly allocated StatusStruct with status VariantAlsoNegotiates
+func VariantAlsoNegotiates(msg string) StatusStruct {
+	return New(http.StatusVariantAlsoNegotiates, msg)
+}
+
+// InsufficientStorage returns a newly allocated StatusStruct with status InsufficientStorage
+func InsufficientStorage(msg string) StatusStruct {
+	return New(http.StatusInsufficientStorage, msg)
+}
+
+// LoopDetected returns a newly allocated StatusStruct with status LoopDetected
+func LoopDetected(msg string) StatusStruct {
+	return New(http.StatusLoopDetected, msg)
+}
+
+// NotExtended returns a newly allocated StatusStruct with status NotExtended
+func NotExtended(msg string) StatusStruct {
+	return New(http.StatusNotExtended, msg)
+}
+
+// NetworkAuthenticationRequired returns a newly allocated StatusStruct with status NetworkAuthenticationRequired
+func NetworkAuthenticationRequired(msg string) StatusStruct {
+	return New(http.StatusNetworkAuthenticationRequired, msg)
+}
+
+// HasCode reports whether err, or any error it wraps, is an Http error with the given status code
+func HasCode(err error, code int) bool {
+	var httpErr Http
+	if e, ok := err.(Http); ok {
+		httpErr = e
+	} else if !stderrors.As(err, &httpErr) {
+		return false
+	}
+	return httpErr.Code() == code
+}
+
+// errorString formats err as "<code> <status text>: <message>"
+func errorString(err Http) string {
+	return fmt.Sprintf("%d %s: %s", err.Code(), http.StatusText(err.Code()), err.Message())
+}
+
+// hasCode reports whether target is an Http error with the given status code
+func hasCode(target error, code int) bool {
+	t, ok := target.(Http)
+	return ok && t.Code() == code
+}
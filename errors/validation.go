@@ -0,0 +1,91 @@
+package errors
+
+import (
+	"net/http"
+)
+
+// FieldError describes why a single field of the request is invalid
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`
+	Code    string `json:"code" xml:"code"`
+	Message string `json:"message" xml:"message"`
+}
+
+/*
+*	Validation errors
+ */
+// ValidationStruct http error carrying the list of invalid fields.
+// Status defaults to UnprocessableEntity, set it to http.StatusBadRequest if preferred
+type ValidationStruct struct {
+	Status int          `json:"-"`
+	Msg    string       `json:"message"`
+	Fields []FieldError `json:"fields"`
+}
+
+// Validation returns a newly allocated ValidationStruct without any field errors.
+// Field errors are accumulated with Add and the result returned with Err:
+//
+//	v := errors.Validation("invalid user")
+//	if user.Email == "" {
+//		v.Add("email", "required", "email is required")
+//	}
+//	if err := v.Err(); err != nil {
+//		return err
+//	}
+func Validation(msg string) ValidationStruct {
+	return ValidationStruct{Status: http.StatusUnprocessableEntity, Msg: msg, Fields: []FieldError{}}
+}
+
+// Add appends a field error
+func (e *ValidationStruct) Add(field, code, message string) {
+	e.Fields = append(e.Fields, FieldError{field, code, message})
+}
+
+// HasErrors reports whether any field error was added
+func (e ValidationStruct) HasErrors() bool {
+	return len(e.Fields) > 0
+}
+
+// Err returns the validation error if any field error was added, nil otherwise
+func (e ValidationStruct) Err() Http {
+	if !e.HasErrors() {
+		return nil
+	}
+	return e
+}
+
+// Message - needed to implement HttpErrors interface
+func (e ValidationStruct) Message() string {
+	return e.Msg
+}
+
+// Code - needed to implement Http interface
+func (e ValidationStruct) Code() int {
+	return e.Status
+}
+
+// Error - needed to implement error interface
+func (e ValidationStruct) Error() string {
+	return errorString(e)
+}
+
+// Is reports whether target has the same status code. Used by the standard errors.Is
+func (e ValidationStruct) Is(target error) bool {
+	return hasCode(target, e.Status)
+}
+
+// Unwrap - there is no underlying cause
+func (e ValidationStruct) Unwrap() error {
+	return nil
+}
+
+// Fields returns the field errors carried by err, if any
+func Fields(err Http) []FieldError {
+	switch e := err.(type) {
+	case ValidationStruct:
+		return e.Fields
+	case *ValidationStruct:
+		return e.Fields
+	}
+	return nil
+}
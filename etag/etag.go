@@ -0,0 +1,162 @@
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asvins/router"
+	"github.com/asvins/router/errors"
+)
+
+// Options of the ETag middleware
+type Options struct {
+	// Weak makes the computed ETags weak, e.g. when a compression middleware wraps this one.
+	// If-Match uses the strong comparison, so it never matches weak ETags
+	Weak bool
+
+	// Current returns the ETag and Last-Modified time of the resource of a PUT, PATCH or DELETE request,
+	// and whether it exists, to evaluate If-Match and If-Unmodified-Since. The ETag must be the one its GET
+	// responses have. If nil, those conditions are ignored
+	Current func(rq *http.Request) (etag string, lastModified time.Time, exists bool)
+}
+
+// ETag is a middleware adding ETags to the responses of GET and HEAD requests and evaluating conditional requests.
+// The 200 responses are buffered and tagged with a hash of their body, unless the handler set an ETag itself.
+// If-None-Match and If-Modified-Since get a 304 when the client copy is current.
+// PUT, PATCH and DELETE requests with If-Match or If-Unmodified-Since are checked against the ETag
+// and Last-Modified given by Options.Current, and get a 412 when they don't match, for optimistic concurrency
+type ETag struct {
+	router  *router.Router
+	options Options
+}
+
+// New = constructor for ETag. The router writes the 412 errors
+func New(r *router.Router, options Options) *ETag {
+	return &ETag{router: r, options: options}
+}
+
+// Middleware - needed to be used as router.Middleware
+func (e *ETag) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		switch rq.Method {
+		case http.MethodGet, http.MethodHead:
+			e.serveCacheable(next, w, rq)
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			if err := e.checkPreconditions(rq); err != nil {
+				e.router.WriteError(w, rq, err, "ETag")
+				return
+			}
+			next.ServeHTTP(w, rq)
+		default:
+			next.ServeHTTP(w, rq)
+		}
+	})
+}
+
+// serveCacheable buffers the response to tag it, answering 304 when the client copy is current
+func (e *ETag) serveCacheable(next http.Handler, w http.ResponseWriter, rq *http.Request) {
+	bw := &bufferWriter{ResponseWriter: w}
+	next.ServeHTTP(bw, rq)
+	if bw.passthrough {
+		return
+	}
+
+	header := w.Header()
+	if bw.Status() == http.StatusOK {
+		if header.Get("ETag") == "" && (bw.buf.Len() > 0 || rq.Method == http.MethodGet) {
+			header.Set("ETag", Compute(bw.buf.Bytes(), e.options.Weak))
+		}
+
+		if notModified(rq, header) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		if header.Get("Content-Length") == "" && header.Get("Content-Encoding") == "" {
+			header.Set("Content-Length", strconv.Itoa(bw.buf.Len()))
+		}
+	}
+
+	w.WriteHeader(bw.Status())
+	w.Write(bw.buf.Bytes())
+}
+
+// Compute returns the ETag the middleware computes for body, e.g. for Options.Current to tag the current
+// resource as its GET responses are
+func Compute(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	tag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since without it, against the response headers
+func notModified(rq *http.Request, header http.Header) bool {
+	if ifNoneMatch := rq.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return matches(ifNoneMatch, header.Get("ETag"), false)
+	}
+
+	lastModified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	since, err := http.ParseTime(rq.Header.Get("If-Modified-Since"))
+	return err == nil && !lastModified.Truncate(time.Second).After(since)
+}
+
+// checkPreconditions evaluates If-Match, or If-Unmodified-Since without it, against Options.Current
+func (e *ETag) checkPreconditions(rq *http.Request) errors.Http {
+	ifMatch := rq.Header.Get("If-Match")
+	ifUnmodifiedSince := rq.Header.Get("If-Unmodified-Since")
+	if e.options.Current == nil || ifMatch == "" && ifUnmodifiedSince == "" {
+		return nil
+	}
+
+	tag, lastModified, exists := e.options.Current(rq)
+
+	if ifMatch != "" {
+		if exists && (strings.TrimSpace(ifMatch) == "*" || matches(ifMatch, tag, true)) {
+			return nil
+		}
+		return errors.PreconditionFailed("Precondition failed").WithCode("etag_mismatch")
+	}
+
+	since, err := http.ParseTime(ifUnmodifiedSince)
+	if err != nil || !exists || lastModified.IsZero() || !lastModified.Truncate(time.Second).After(since) {
+		return nil
+	}
+	return errors.PreconditionFailed("Precondition failed").WithCode("modified")
+}
+
+// matches tells if the list of ETags of a condition header has tag.
+// The strong comparison, used by If-Match, doesn't match weak ETags
+func matches(list, tag string, strong bool) bool {
+	if tag == "" {
+		return false
+	}
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if strong && strings.HasPrefix(tag, "W/") {
+		return false
+	}
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong && strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package etag
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asvins/router"
+)
+
+func newRouter(options Options) (*router.Router, *string) {
+	order := `{"id": 1, "status": "open"}`
+	options.Current = func(rq *http.Request) (string, time.Time, bool) {
+		if rq.URL.Path == "/report" {
+			return "", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC), true
+		}
+		return Compute([]byte(order), false), time.Time{}, strings.HasPrefix(rq.URL.Path, "/orders/")
+	}
+
+	r := router.NewRouter()
+	r.MaxBodySize = 1024
+	r.Use(New(r, options).Middleware)
+	r.AddRoute("/orders/:id", router.GET, func(w http.ResponseWriter, rq *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, order)
+	})
+	r.AddRoute("/orders/:id", router.PUT, func(w http.ResponseWriter, rq *http.Request) {
+		body, _ := io.ReadAll(rq.Body)
+		order = string(body)
+	})
+	r.AddRoute("/orders/:id", router.DELETE, func(w http.ResponseWriter, rq *http.Request) {})
+	r.AddRoute("/report", router.GET, func(w http.ResponseWriter, rq *http.Request) {
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		io.WriteString(w, "report")
+	})
+	return r, &order
+}
+
+func do(r *router.Router, method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+	rq := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range header {
+		rq.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, rq)
+	return w
+}
+
+func TestConditionalGet(t *testing.T) {
+	r, _ := newRouter(Options{})
+
+	w := do(r, "GET", "/orders/1", "", nil)
+	tag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || len(tag) != 34 || w.Header().Get("Content-Length") != "27" {
+		t.Fatal("GET should be tagged Got", w.Code, w.Header())
+	}
+
+	w = do(r, "GET", "/orders/1", "", map[string]string{"If-None-Match": `"other", ` + tag})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != tag {
+		t.Error("Current copy should be Not Modified Got", w.Code, w.Header())
+	}
+
+	w = do(r, "GET", "/orders/1", "", map[string]string{"If-None-Match": "W/" + tag})
+	if w.Code != http.StatusNotModified {
+		t.Error("If-None-Match should use the weak comparison Got", w.Code)
+	}
+
+	w = do(r, "GET", "/orders/1", "", map[string]string{"If-None-Match": `"other"`})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "open") {
+		t.Error("Stale copy should get the body Got", w.Code, w.Body.String())
+	}
+
+	w = do(r, "GET", "/report", "", map[string]string{"If-Modified-Since": "Mon, 02 Jan 2006 15:04:05 GMT"})
+	if w.Code != http.StatusNotModified {
+		t.Error("Unmodified report should be Not Modified Got", w.Code)
+	}
+	w = do(r, "GET", "/report", "", map[string]string{"If-Modified-Since": "Mon, 02 Jan 2006 15:00:00 GMT"})
+	if w.Code != http.StatusOK || w.Body.String() != "report" {
+		t.Error("Modified report should get the body Got", w.Code)
+	}
+
+	if w = do(r, "GET", "/missing", "", map[string]string{"If-None-Match": "*"}); w.Code != http.StatusNotFound || w.Header().Get("ETag") != "" {
+		t.Error("Errors should not be tagged Got", w.Code, w.Header())
+	}
+
+	weak, _ := newRouter(Options{Weak: true})
+	if w = do(weak, "GET", "/orders/1", "", nil); !strings.HasPrefix(w.Header().Get("ETag"), `W/"`) {
+		t.Error("ETag should be weak Got", w.Header().Get("ETag"))
+	}
+}
+
+func TestPreconditions(t *testing.T) {
+	r, order := newRouter(Options{})
+	tag := do(r, "GET", "/orders/1", "", nil).Header().Get("ETag")
+
+	update := `{"id": 1, "status": "paid"}`
+	w := do(r, "PUT", "/orders/1", update, map[string]string{"If-Match": tag})
+	if w.Code != http.StatusOK || *order != update {
+		t.Fatal("Update with the current ETag should succeed Got", w.Code, *order)
+	}
+
+	w = do(r, "PUT", "/orders/1", `{"id": 1, "status": "cancelled"}`, map[string]string{"If-Match": tag})
+	if w.Code != http.StatusPreconditionFailed || *order != update || !strings.Contains(w.Body.String(), `"code":"etag_mismatch"`) {
+		t.Error("Update with a stale ETag should fail Got", w.Code, w.Body.String())
+	}
+
+	if w = do(r, "DELETE", "/orders/1", "", map[string]string{"If-Match": "W/" + tag}); w.Code != http.StatusPreconditionFailed {
+		t.Error("If-Match should use the strong comparison Got", w.Code)
+	}
+	if w = do(r, "DELETE", "/orders/1", "", map[string]string{"If-Match": "*"}); w.Code != http.StatusOK {
+		t.Error("If-Match * should match an existing resource Got", w.Code)
+	}
+	r.AddRoute("/missing", router.DELETE, func(w http.ResponseWriter, rq *http.Request) {})
+	if w = do(r, "DELETE", "/missing", "", map[string]string{"If-Match": "*"}); w.Code != http.StatusPreconditionFailed {
+		t.Error("If-Match * should not match a missing resource Got", w.Code)
+	}
+	if w = do(r, "DELETE", "/orders/1", "", nil); w.Code != http.StatusOK {
+		t.Error("Unconditional requests should not be checked Got", w.Code)
+	}
+
+	since := time.Date(2006, 1, 2, 15, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+	r.AddRoute("/report", router.PUT, func(w http.ResponseWriter, rq *http.Request) {})
+	if w = do(r, "PUT", "/report", "", map[string]string{"If-Unmodified-Since": since}); w.Code != http.StatusPreconditionFailed {
+		t.Error("Report modified since should fail Got", w.Code)
+	}
+}
+
+func TestPreconditionsWithoutSideEffects(t *testing.T) {
+	gets := 0
+	r := router.NewRouter()
+	r.Use(New(r, Options{}).Middleware)
+	r.AddRoute("/orders/:id", router.GET, func(w http.ResponseWriter, rq *http.Request) { gets++ })
+	r.AddRoute("/orders/:id", router.PUT, func(w http.ResponseWriter, rq *http.Request) {})
+
+	if w := do(r, "PUT", "/orders/1", "", map[string]string{"If-Match": `"stale"`}); w.Code != http.StatusOK || gets != 0 {
+		t.Error("Preconditions should be ignored without Current and not run the GET Got", w.Code, gets)
+	}
+}
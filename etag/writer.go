@@ -0,0 +1,76 @@
+package etag
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+)
+
+// bufferWriter buffers the response to tag it once complete.
+// Flushing or hijacking passes the response through untagged
+type bufferWriter struct {
+	http.ResponseWriter
+	status      int
+	buf         bytes.Buffer
+	passthrough bool
+}
+
+// WriteHeader records the status code, written once the response is complete
+func (w *bufferWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+// Write buffers b
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+// Status returns the status code written, http.StatusOK if none was written
+func (w *bufferWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Flush - needed to implement http.Flusher. Streamed responses aren't tagged
+func (w *bufferWriter) Flush() {
+	if !w.passthrough {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(w.Status())
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack - needed to implement http.Hijacker, e.g. for websockets
+func (w *bufferWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	w.passthrough = true
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the wrapped writer, used by http.ResponseController
+func (w *bufferWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,192 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/asvins/router/errors"
+)
+
+// Catalog holds the localized error messages, keyed by locale and error code.
+// The code of an error is its application code (errors.Coded) or, if it has none, its status code, e.g. "404".
+// Messages are text/template templates executed with the error details (errors.Detailed).
+// Field errors of validation errors are localized by their code and executed with the field name as .field
+type Catalog struct {
+	messages map[string]map[string]*template.Template
+	names    map[string]string
+
+	// Fallback are the locales tried, in order, when none of the client locales has the message
+	Fallback []string
+}
+
+// NewCatalog = constructor for Catalog
+func NewCatalog(fallback ...string) *Catalog {
+	return &Catalog{
+		messages: make(map[string]map[string]*template.Template),
+		names:    make(map[string]string),
+		Fallback: fallback,
+	}
+}
+
+// Add adds messages keyed by error code to the given locale, e.g. "pt-BR"
+func (c *Catalog) Add(locale string, messages map[string]string) error {
+	name := locale
+	locale = strings.ToLower(locale)
+	c.names[locale] = name
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]*template.Template)
+	}
+
+	for code, message := range messages {
+		// missing params fail, so the next locale or the original message is used instead of "<no value>"
+		tmpl, err := template.New(code).Option("missingkey=error").Parse(message)
+		if err != nil {
+			return err
+		}
+		c.messages[locale][code] = tmpl
+	}
+	return nil
+}
+
+// LoadFile adds the messages of a JSON file, an object of error code to message, to the given locale
+func (c *Catalog) LoadFile(locale string, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	messages := make(map[string]string)
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return err
+	}
+	return c.Add(locale, messages)
+}
+
+// LoadDir loads every JSON file of dir, using the file name as locale, e.g. locales/pt-BR.json
+func (c *Catalog) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		locale := strings.TrimSuffix(filepath.Base(path), ".json")
+		if err := c.LoadFile(locale, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Translate returns the message for code in the locale that best matches the Accept-Language header,
+// the locale used and whether a message was found
+func (c *Catalog) Translate(acceptLanguage string, code string, params interface{}) (string, string, bool) {
+	for _, locale := range c.locales(acceptLanguage) {
+		tmpl, ok := c.messages[locale][code]
+		if !ok {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, params); err != nil {
+			continue
+		}
+		return buf.String(), c.names[locale], true
+	}
+	return "", "", false
+}
+
+// locales returns the catalog locales to try for the Accept-Language header, in order.
+// Each client locale is followed by its base language and the regional variants of it (pt-pt -> pt, pt-br),
+// then the fallback locales
+func (c *Catalog) locales(acceptLanguage string) []string {
+	var locales []string
+	for _, ar := range parseAccept(acceptLanguage) {
+		if ar.mediaType == "*" {
+			break
+		}
+
+		base := ar.mediaType
+		if i := strings.Index(base, "-"); i > 0 {
+			base = base[:i]
+		}
+
+		locales = append(locales, ar.mediaType, base)
+		locales = append(locales, c.variants(base)...)
+	}
+
+	for _, locale := range c.Fallback {
+		locales = append(locales, strings.ToLower(locale))
+	}
+	return locales
+}
+
+// variants returns the catalog locales of the base language, sorted
+func (c *Catalog) variants(base string) []string {
+	var variants []string
+	for locale := range c.messages {
+		if strings.HasPrefix(locale, base+"-") {
+			variants = append(variants, locale)
+		}
+	}
+	sort.Strings(variants)
+	return variants
+}
+
+// localize returns err with its message, and the message of its field errors, translated
+// to the request language. The second return is the locale used, empty if nothing was translated
+func (c *Catalog) localize(acceptLanguage string, err errors.Http) (errors.Http, string) {
+	code := strconv.Itoa(err.Code())
+	if coded, ok := err.(errors.Coded); ok && coded.ErrorCode() != "" {
+		code = coded.ErrorCode()
+	}
+
+	var params map[string]interface{}
+	if detailed, ok := err.(errors.Detailed); ok {
+		params = detailed.ErrorDetails()
+	}
+
+	// errors of other types are written as they are, converting them would lose their fields
+	if !errors.CanLocalize(err) {
+		return err, ""
+	}
+
+	message, locale, found := c.Translate(acceptLanguage, code, params)
+	if found {
+		err = errors.WithMessage(err, message)
+	}
+
+	switch v := err.(type) {
+	case errors.ValidationStruct:
+		v.Fields, locale = c.localizeFields(acceptLanguage, v.Fields, locale)
+		err = v
+	case *errors.ValidationStruct:
+		copied := *v
+		copied.Fields, locale = c.localizeFields(acceptLanguage, v.Fields, locale)
+		err = &copied
+	}
+
+	return err, locale
+}
+
+// localizeFields returns a copy of fields with their messages translated, and the locale of the main message
+// or, if it wasn't translated, the one of the fields
+func (c *Catalog) localizeFields(acceptLanguage string, fields []errors.FieldError, locale string) ([]errors.FieldError, string) {
+	localized := make([]errors.FieldError, len(fields))
+	for i, field := range fields {
+		localized[i] = field
+		if message, fieldLocale, ok := c.Translate(acceptLanguage, field.Code, map[string]interface{}{"field": field.Field}); ok {
+			localized[i].Message = message
+			if locale == "" {
+				locale = fieldLocale
+			}
+		}
+	}
+	return localized, locale
+}
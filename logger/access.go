@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/asvins/router"
+)
+
+// Format of the access log lines
+type Format int
+
+const (
+	// JSON writes one JSON object per line
+	JSON Format = iota
+	// Common writes the Apache Common Log Format
+	Common
+	// Combined writes the Apache Combined Log Format, the Common one plus referer and user agent
+	Combined
+)
+
+// Entry is what is logged for each request
+type Entry struct {
+	Time      time.Time     `json:"time"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Route     string        `json:"route,omitempty"`
+	Status    int           `json:"status"`
+	Bytes     int           `json:"bytes"`
+	Duration  time.Duration `json:"-"`
+	RemoteIP  string        `json:"remote_ip"`
+	RequestID string        `json:"request_id,omitempty"`
+
+	// used by the Combined format
+	RequestURI string `json:"-"`
+	Proto      string `json:"-"`
+	Referer    string `json:"-"`
+	UserAgent  string `json:"-"`
+}
+
+// AccessLogger logs every request after its response is written.
+// It's a router.Middleware:
+//
+//	r.Use(logger.NewAccessLogger(os.Stdout, logger.JSON).Middleware)
+type AccessLogger struct {
+	// Output receives the log lines. Defaults to os.Stdout
+	Output io.Writer
+
+	// Format of the lines written to Output
+	Format Format
+
+	// Slog, if set, receives one record per request instead of Output
+	Slog *slog.Logger
+
+	mu sync.Mutex
+}
+
+// NewAccessLogger = constructor for AccessLogger writing lines in the given format
+func NewAccessLogger(output io.Writer, format Format) *AccessLogger {
+	return &AccessLogger{Output: output, Format: format}
+}
+
+// NewSlogAccessLogger = constructor for AccessLogger writing records to a slog.Logger
+func NewSlogAccessLogger(logger *slog.Logger) *AccessLogger {
+	return &AccessLogger{Slog: logger}
+}
+
+// Middleware - needed to be used as router.Middleware
+func (l *AccessLogger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		start := time.Now()
+
+		// the router adds the route params to the query, so keep the request as it came
+		entry := Entry{
+			Time:       start,
+			Method:     rq.Method,
+			Path:       rq.URL.Path,
+			RemoteIP:   remoteIP(rq),
+			RequestURI: rq.URL.RequestURI(),
+			Proto:      rq.Proto,
+			Referer:    rq.Referer(),
+			UserAgent:  rq.UserAgent(),
+		}
+
+		rw := router.NewResponseWriter(w)
+		next.ServeHTTP(rw, rq)
+
+		entry.Route = router.Pattern(rq)
+		entry.RequestID = router.RequestID(rq)
+		entry.Status = rw.Status()
+		entry.Bytes = rw.Size()
+		entry.Duration = time.Since(start)
+		l.Log(rq, entry)
+	})
+}
+
+// Log writes the entry to the slog.Logger or to the output in the configured format
+func (l *AccessLogger) Log(rq *http.Request, entry Entry) {
+	if l.Slog != nil {
+		l.Slog.LogAttrs(rq.Context(), slog.LevelInfo, "request",
+			slog.String("method", entry.Method),
+			slog.String("path", entry.Path),
+			slog.String("route", entry.Route),
+			slog.Int("status", entry.Status),
+			slog.Int("bytes", entry.Bytes),
+			slog.Duration("duration", entry.Duration),
+			slog.String("remote_ip", entry.RemoteIP),
+			slog.String("request_id", entry.RequestID),
+		)
+		return
+	}
+
+	var line string
+	switch l.Format {
+	case Common:
+		line = commonLine(entry) + "\n"
+	case Combined:
+		line = fmt.Sprintf("%s %q %q\n", commonLine(entry), orDash(entry.Referer), orDash(entry.UserAgent))
+	default:
+		line = jsonLine(entry) + "\n"
+	}
+
+	output := l.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(output, line)
+}
+
+// jsonLine returns the entry as a JSON object, with the duration in milliseconds
+func jsonLine(entry Entry) string {
+	data, _ := json.Marshal(struct {
+		Entry
+		DurationMS float64 `json:"duration_ms"`
+	}{entry, float64(entry.Duration) / float64(time.Millisecond)})
+	return string(data)
+}
+
+// commonLine returns the entry in the Apache Common Log Format
+func commonLine(entry Entry) string {
+	size := "-"
+	if entry.Bytes > 0 {
+		size = fmt.Sprint(entry.Bytes)
+	}
+	return fmt.Sprintf(`%s - - [%s] "%s %s %s" %d %s`,
+		orDash(entry.RemoteIP), entry.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		entry.Method, entry.RequestURI, entry.Proto, entry.Status, size)
+}
+
+// remoteIP returns the IP of the client, without the port
+func remoteIP(rq *http.Request) string {
+	host, _, err := net.SplitHostPort(rq.RemoteAddr)
+	if err != nil {
+		return rq.RemoteAddr
+	}
+	return host
+}
+
+// orDash returns s, or "-" if it's empty, as the Apache formats do
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asvins/router"
+)
+
+func TestAccessLogger(t *testing.T) {
+	r := router.NewRouter()
+	r.AddRoute("/user/:uid", router.GET, func(w http.ResponseWriter, rq *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "hello")
+	})
+
+	var out bytes.Buffer
+	access := NewAccessLogger(&out, JSON)
+	r.Use(access.Middleware)
+
+	rq := httptest.NewRequest(router.GET, "/user/42?verbose=1", nil)
+	rq.RemoteAddr = "10.0.0.1:54321"
+	rq.Header.Set("User-Agent", "test")
+	r.ServeHTTP(httptest.NewRecorder(), rq)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatal("Access log line should be JSON. Got", out.String())
+	}
+
+	expected := map[string]interface{}{
+		"method":    "GET",
+		"path":      "/user/42",
+		"route":     "/user/:uid",
+		"status":    float64(http.StatusCreated),
+		"bytes":     float64(5),
+		"remote_ip": "10.0.0.1",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Error(key, "should be", value, " Got", entry[key])
+		}
+	}
+
+	if _, ok := entry["duration_ms"]; !ok {
+		t.Error("duration_ms should be logged. Got", out.String())
+	}
+
+	out.Reset()
+	access.Format = Combined
+	rq = httptest.NewRequest(router.GET, "/user/42?verbose=1", nil)
+	rq.RemoteAddr = "10.0.0.1:54321"
+	rq.Header.Set("User-Agent", "test")
+	r.ServeHTTP(httptest.NewRecorder(), rq)
+	if !strings.HasPrefix(out.String(), "10.0.0.1 - - [") || !strings.HasSuffix(out.String(), `"GET /user/42?verbose=1 HTTP/1.1" 201 5 "-" "test"`+"\n") {
+		t.Error("Unexpected Combined log line. Got", out.String())
+	}
+}
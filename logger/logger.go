@@ -8,6 +8,7 @@ import (
 )
 
 //Logger is a dummy example of possible interceptor
+//It logs before the handler runs, so it doesn't know the status or latency. Use AccessLogger for access logs
 type Logger struct{}
 
 func NewLogger() *Logger {
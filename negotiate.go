@@ -0,0 +1,320 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/asvins/router/errors"
+)
+
+// Formats errors can be rendered in
+const (
+	FormatJSON = "application/json"
+	FormatXML  = "application/xml"
+	FormatText = "text/plain"
+	FormatHTML = "text/html"
+
+	FormatProblemJSON = "application/problem+json"
+)
+
+// errorFormats are the formats offered to the client, in order of preference when the client doesn't have one
+var errorFormats = []string{FormatJSON, FormatXML, FormatText, FormatHTML}
+
+// formatAliases are media types accepted as one of the errorFormats
+var formatAliases = map[string]string{
+	"text/xml":        FormatXML,
+	FormatProblemJSON: FormatJSON,
+}
+
+// DefaultErrorTemplate is the template used to render errors as HTML when the router doesn't have an ErrorTemplate
+var DefaultErrorTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Code}} {{.Status}}</title></head>
+<body>
+<h1>{{.Code}} {{.Status}}</h1>
+<p>{{.Message}}</p>
+{{if .Fields}}<ul>
+{{range .Fields}}<li>{{.Field}}: {{.Message}}</li>
+{{end}}</ul>
+{{end}}{{with .RequestID}}<p><small>Request ID: {{.}}</small></p>
+{{end}}{{with .Debug}}<h2>{{.Origin}}</h2>
+{{range .Causes}}<p>caused by: {{.}}</p>
+{{end}}<pre>{{range .Stack}}{{.}}
+{{end}}</pre>
+{{end}}</body>
+</html>
+`))
+
+// ErrorView is the data given to the HTML error template
+type ErrorView struct {
+	Code      int
+	Status    string
+	Message   string
+	AppCode   string
+	Details   map[string]interface{}
+	Fields    []errors.FieldError
+	RequestID string
+	Debug     *DebugInfo
+	Err       errors.Http
+}
+
+// xmlError is the body of errors rendered as XML
+type xmlError struct {
+	XMLName   xml.Name   `xml:"error"`
+	Code      int        `xml:"code"`
+	AppCode   string     `xml:"errorCode,omitempty"`
+	Message   string     `xml:"message"`
+	Details   xmlDetails `xml:"details,omitempty"`
+	Fields    *xmlFields `xml:"fields,omitempty"`
+	RequestID string     `xml:"requestId,omitempty"`
+	Debug     *DebugInfo `xml:"debug,omitempty"`
+}
+
+// xmlFields is the list of field errors of errors rendered as XML
+type xmlFields struct {
+	Field []errors.FieldError `xml:"field"`
+}
+
+// xmlDetails are the details of errors rendered as XML, one detail element per key:
+//
+//	<details><detail key="id">42</detail></details>
+type xmlDetails map[string]interface{}
+
+// MarshalXML - needed to implement xml.Marshaler, as maps can't be encoded. Keys are sorted
+func (d xmlDetails) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	keys := make([]string, 0, len(d))
+	for key := range d {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		detail := xml.StartElement{Name: xml.Name{Local: "detail"}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}}}
+
+		var value interface{} = d[key]
+		if nested, ok := value.(map[string]interface{}); ok {
+			value = xmlDetails(nested)
+		} else if value != nil && reflect.TypeOf(value).Kind() == reflect.Map {
+			value = fmt.Sprint(value)
+		}
+
+		if err := e.EncodeElement(value, detail); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// renderError writes err in the format that best matches the request Accept header.
+// The request ID, if any, and debug, if not nil, are added to the body
+func (r *Router) renderError(w http.ResponseWriter, rq *http.Request, err errors.Http, debug *DebugInfo) {
+	w.Header().Add("Vary", "Accept")
+	requestID := RequestID(rq)
+
+	switch r.errorFormat(rq) {
+	case FormatXML:
+		body := xmlError{Code: err.Code(), Message: err.Message(), RequestID: requestID, Debug: debug}
+		if coded, ok := err.(errors.Coded); ok {
+			body.AppCode = coded.ErrorCode()
+		}
+		if detailed, ok := err.(errors.Detailed); ok {
+			body.Details = detailed.ErrorDetails()
+		}
+		if fields := errors.Fields(err); len(fields) > 0 {
+			body.Fields = &xmlFields{fields}
+		}
+		r.renderer().XML(typedWriter{w, FormatXML + "; charset=UTF-8"}, err.Code(), body)
+	case FormatText:
+		r.renderer().Text(w, err.Code(), textError(err, requestID, debug))
+	case FormatHTML:
+		r.renderHTMLError(w, err, requestID, debug)
+	default:
+		r.renderJSONError(w, rq, err, requestID, debug)
+	}
+}
+
+// renderJSONError writes err as JSON, or as a problem details document
+// if problem details are enabled or err already is one
+func (r *Router) renderJSONError(w http.ResponseWriter, rq *http.Request, err errors.Http, requestID string, debug *DebugInfo) {
+	members := make(map[string]interface{})
+	if requestID != "" {
+		members["request_id"] = requestID
+	}
+	if debug != nil {
+		members["debug"] = debug
+	}
+
+	_, isProblem := err.(errors.ProblemStruct)
+	if !r.ProblemDetails && !isProblem {
+		r.renderer().JSON(w, err.Code(), withMembers(err, members))
+		return
+	}
+
+	problem := errors.ToProblem(err)
+	if problem.Instance == "" {
+		problem.Instance = rq.URL.Path
+	}
+	for key, value := range members {
+		problem = problem.With(key, value)
+	}
+
+	r.renderer().JSON(typedWriter{w, FormatProblemJSON}, problem.Code(), problem)
+}
+
+// withMembers returns the JSON body of err with members added, or err itself if there are none
+func withMembers(err errors.Http, members map[string]interface{}) interface{} {
+	if len(members) == 0 {
+		return err
+	}
+
+	data, marshalErr := json.Marshal(err)
+	if marshalErr != nil {
+		return err
+	}
+
+	body := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if decoder.Decode(&body) != nil {
+		return err
+	}
+
+	for key, value := range members {
+		body[key] = value
+	}
+	return body
+}
+
+// renderHTMLError executes the error template and writes the result
+func (r *Router) renderHTMLError(w http.ResponseWriter, err errors.Http, requestID string, debug *DebugInfo) {
+	tmpl := r.ErrorTemplate
+	if tmpl == nil {
+		tmpl = DefaultErrorTemplate
+	}
+
+	var buf bytes.Buffer
+	view := ErrorView{Code: err.Code(), Status: http.StatusText(err.Code()), Message: err.Message(), Fields: errors.Fields(err), RequestID: requestID, Debug: debug, Err: err}
+	if coded, ok := err.(errors.Coded); ok {
+		view.AppCode = coded.ErrorCode()
+	}
+	if detailed, ok := err.(errors.Detailed); ok {
+		view.Details = detailed.ErrorDetails()
+	}
+	if tmplErr := tmpl.Execute(&buf, view); tmplErr != nil {
+		r.renderer().Text(w, err.Code(), textError(err, requestID, debug))
+		return
+	}
+
+	w.Header().Set("Content-Type", FormatHTML+"; charset=UTF-8")
+	w.WriteHeader(err.Code())
+	w.Write(buf.Bytes())
+}
+
+// textError returns the message of err followed by one line per field error, the request ID and the debug information, if any
+func textError(err errors.Http, requestID string, debug *DebugInfo) string {
+	text := err.Message()
+	for _, field := range errors.Fields(err) {
+		text += "\n" + field.Field + ": " + field.Message
+	}
+
+	if requestID != "" {
+		text += "\nrequest id: " + requestID
+	}
+
+	if debug != nil {
+		text += "\n\norigin: " + debug.Origin
+		for _, cause := range debug.Causes {
+			text += "\ncaused by: " + cause
+		}
+		for _, frame := range debug.Stack {
+			text += "\n\t" + frame
+		}
+	}
+	return text
+}
+
+// errorFormat returns the format errors should be rendered in for rq
+func (r *Router) errorFormat(rq *http.Request) string {
+	def := r.DefaultErrorFormat
+	if def == "" {
+		def = FormatJSON
+	}
+	return negotiate(rq.Header.Get("Accept"), errorFormats, def)
+}
+
+// acceptRange is a media range of the Accept header with its quality
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept parses an Accept header into its media ranges, sorted by quality
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		if q > 0 {
+			ranges = append(ranges, acceptRange{mediaType, q})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges
+}
+
+// negotiate returns the offer that best matches the Accept header.
+// def is returned if the header is empty, accepts anything or doesn't match any offer
+func negotiate(header string, offers []string, def string) string {
+	for _, ar := range parseAccept(header) {
+		if ar.mediaType == "*/*" {
+			return def
+		}
+
+		for _, offer := range offers {
+			if ar.mediaType == offer || formatAliases[ar.mediaType] == offer {
+				return offer
+			}
+		}
+
+		if strings.HasSuffix(ar.mediaType, "/*") {
+			prefix := strings.TrimSuffix(ar.mediaType, "*")
+			if strings.HasPrefix(def, prefix) {
+				return def
+			}
+			for _, offer := range offers {
+				if strings.HasPrefix(offer, prefix) {
+					return offer
+				}
+			}
+		}
+	}
+	return def
+}
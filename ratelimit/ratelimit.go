@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/asvins/router"
+	"github.com/asvins/router/auth"
+	"github.com/asvins/router/errors"
+)
+
+// KeyFunc returns the key requests are limited by. Requests with an empty key aren't limited
+type KeyFunc func(rq *http.Request) string
+
+// ByIP limits each client IP. Behind a proxy, use a KeyFunc reading the header the proxy sets
+func ByIP(rq *http.Request) string {
+	host, _, err := net.SplitHostPort(rq.RemoteAddr)
+	if err != nil {
+		return rq.RemoteAddr
+	}
+	return host
+}
+
+// ByHeader limits each value of the header, e.g. an API key
+func ByHeader(name string) KeyFunc {
+	return func(rq *http.Request) string {
+		return rq.Header.Get(name)
+	}
+}
+
+// ByPrincipal limits each principal authenticated by the auth interceptors
+func ByPrincipal(rq *http.Request) string {
+	if principal := auth.FromRequest(rq); principal != nil {
+		return principal.Name
+	}
+	return ""
+}
+
+// ByRoute limits each route pattern, shared by all the clients
+func ByRoute(rq *http.Request) string {
+	return rq.Method + " " + router.Pattern(rq)
+}
+
+// Limiter is an interceptor rejecting the requests over the limit with a 429.
+// Every response gets the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers,
+// and rejected ones Retry-After
+type Limiter struct {
+	Limit Limit
+	Key   KeyFunc
+
+	// Store keeps the state of the limit
+	Store Store
+
+	// Name prefixes the keys, so limiters sharing a Store don't share limits
+	Name string
+
+	// FailOpen allows the requests when the Store fails, instead of returning an error
+	FailOpen bool
+}
+
+// New = constructor for Limiter, with its own MemoryStore. It panics if the limit isn't valid
+func New(limit Limit, key KeyFunc) *Limiter {
+	if err := limit.Validate(); err != nil {
+		panic(err)
+	}
+	return &Limiter{Limit: limit, Key: key, Store: NewMemoryStore(0)}
+}
+
+// Intercept is the Interceptor interface implementation
+func (l *Limiter) Intercept(rw http.ResponseWriter, r *http.Request) errors.Http {
+	key := l.Key(r)
+	if key == "" {
+		return nil
+	}
+
+	result, err := l.Store.Take(l.Name+":"+key, l.Limit)
+	if err != nil {
+		if l.FailOpen {
+			return nil
+		}
+		return errors.Wrap(err, http.StatusInternalServerError, "Rate limit unavailable")
+	}
+
+	header := rw.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.FormatInt(seconds(result.Reset), 10))
+
+	if result.Allowed {
+		return nil
+	}
+	retryAfter := seconds(result.RetryAfter)
+	return errors.TooManyRequests("Rate limit exceeded").
+		WithCode("rate_limited").
+		WithDetail("retry_after", retryAfter).
+		WithHeader("Retry-After", strconv.FormatInt(retryAfter, 10))
+}
+
+// seconds rounds d up to whole seconds, so clients don't retry too early
+func seconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
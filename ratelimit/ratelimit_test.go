@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asvins/router"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func TestTokenBucket(t *testing.T) {
+	c := &clock{time.Unix(1700000000, 0)}
+	store := NewMemoryStore(4)
+	store.Now = c.Now
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	for i := 0; i < 3; i++ {
+		if result, _ := store.Take("a", limit); !result.Allowed || result.Remaining != 2-i {
+			t.Error("Request", i, "should be allowed with", 2-i, "remaining Got", result)
+		}
+	}
+
+	result, _ := store.Take("a", limit)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Error("Request over the burst should wait a second Got", result)
+	}
+	if result, _ := store.Take("b", limit); !result.Allowed {
+		t.Error("Other keys should have their own bucket Got", result)
+	}
+
+	c.now = c.now.Add(time.Second)
+	if result, _ := store.Take("a", limit); !result.Allowed || result.Remaining != 0 || result.Reset != 3*time.Second {
+		t.Error("A token should be refilled after a second Got", result)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	c := &clock{time.Unix(1700000000, 0)}
+	store := NewMemoryStore(1)
+	store.Now = c.Now
+	limit := Limit{Requests: 4, Period: 10 * time.Second, Algorithm: SlidingWindow}
+
+	for i := 0; i < 4; i++ {
+		store.Take("a", limit)
+	}
+	if result, _ := store.Take("a", limit); result.Allowed || result.RetryAfter != 10*time.Second {
+		t.Error("Request over the window should wait for the next one Got", result)
+	}
+
+	// half of the previous window still counts: 4 * 0.5 = 2
+	c.now = c.now.Add(15 * time.Second)
+	for i := 0; i < 2; i++ {
+		if result, _ := store.Take("a", limit); !result.Allowed {
+			t.Error("Request", i, "of the next window should be allowed Got", result)
+		}
+	}
+	if result, _ := store.Take("a", limit); result.Allowed || result.RetryAfter != 2500*time.Millisecond {
+		t.Error("Request over the weighted count should wait for the previous window to slide Got", result)
+	}
+
+	c.now = c.now.Add(time.Minute)
+	if result, _ := store.Take("a", limit); !result.Allowed || result.Remaining != 3 {
+		t.Error("Windows should be forgotten after a period Got", result)
+	}
+}
+
+func TestMemoryStoreConcurrency(t *testing.T) {
+	store := NewMemoryStore(0)
+	limit := Limit{Requests: 100, Period: time.Hour}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result, _ := store.Take("shared", limit); result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 100 {
+		t.Error("Exactly the limit should be allowed Got", allowed)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	limiter := New(Limit{Requests: 2, Period: time.Minute}, ByIP)
+
+	r := router.NewRouter()
+	r.AddRoute("/api/login", router.POST, func(w http.ResponseWriter, rq *http.Request) {}, limiter)
+
+	post := func(addr string) *httptest.ResponseRecorder {
+		rq := httptest.NewRequest("POST", "/api/login", nil)
+		rq.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, rq)
+		return w
+	}
+
+	post("10.0.0.1:1234")
+	w := post("10.0.0.1:1234")
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != "0" || w.Header().Get("RateLimit-Reset") != "60" {
+		t.Error("Allowed requests should have the RateLimit headers Got", w.Code, w.Header())
+	}
+
+	w = post("10.0.0.1:5678")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" || !strings.Contains(w.Body.String(), `"code":"rate_limited"`) {
+		t.Error("Requests over the limit should be rejected with Retry-After Got", w.Code, w.Header(), w.Body.String())
+	}
+
+	if w = post("10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Error("Other clients should not be limited Got", w.Code)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	rq := httptest.NewRequest("GET", "/orders", nil)
+	rq.RemoteAddr = "[::1]:8080"
+	rq.Header.Set("X-API-Key", "k-123")
+
+	if key := ByIP(rq); key != "::1" {
+		t.Error("ByIP should be ::1 Got", key)
+	}
+	if key := ByHeader("X-API-Key")(rq); key != "k-123" {
+		t.Error("ByHeader should be k-123 Got", key)
+	}
+	if key := ByPrincipal(rq); key != "" {
+		t.Error("ByPrincipal should be empty without a principal Got", key)
+	}
+}
+
+func TestInvalidLimits(t *testing.T) {
+	store := NewMemoryStore(1)
+	for _, algorithm := range []Algorithm{TokenBucket, SlidingWindow} {
+		result, err := store.Take("a", Limit{Requests: 0, Period: time.Minute, Algorithm: algorithm})
+		if err != nil || result.Allowed || result.RetryAfter != time.Minute {
+			t.Error("A limit of zero requests should deny them all Got", result, err)
+		}
+
+		if _, err := store.Take("a", Limit{Requests: 1, Algorithm: algorithm}); err == nil {
+			t.Error("A limit without a period should fail")
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("New should panic with a limit without a period")
+		}
+	}()
+	New(Limit{Requests: 1}, ByIP)
+}
@@ -0,0 +1,208 @@
+package ratelimit
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+)
+
+// Algorithm is how requests are counted against a Limit
+type Algorithm int
+
+const (
+	// TokenBucket allows bursts of up to Requests, refilled evenly over the Period
+	TokenBucket Algorithm = iota
+	// SlidingWindow allows Requests in any Period, weighting the previous window by how much of it overlaps
+	SlidingWindow
+)
+
+// Limit is the number of requests allowed per period. A limit of zero requests denies them all
+type Limit struct {
+	Requests  int
+	Period    time.Duration
+	Algorithm Algorithm
+}
+
+// Validate returns an error if the limit can't be evaluated
+func (l Limit) Validate() error {
+	if l.Period <= 0 {
+		return fmt.Errorf("ratelimit: period must be positive, got %v", l.Period)
+	}
+	return nil
+}
+
+// Result of taking a request from a limit
+type Result struct {
+	Allowed bool
+
+	// Limit is the number of requests allowed per period
+	Limit int
+
+	// Remaining is the number of requests still allowed now
+	Remaining int
+
+	// Reset is the time until the limit is fully available again
+	Reset time.Duration
+
+	// RetryAfter is the time until the next request is allowed, zero if it's allowed now
+	RetryAfter time.Duration
+}
+
+// Store keeps the state of the limits. Implement it to share limits between instances, e.g. in Redis.
+// Take must count the request and evaluate the limit atomically
+type Store interface {
+	Take(key string, limit Limit) (Result, error)
+}
+
+// MemoryStore is a Store in memory, sharded to reduce lock contention.
+// Keys unused for longer than their period are removed as other keys are taken
+type MemoryStore struct {
+	shards []*shard
+
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+}
+
+// shard is a part of the keys of a MemoryStore
+type shard struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	nextSweep time.Time
+}
+
+// entry is the state of a key, used as a bucket or a window according to the algorithm
+type entry struct {
+	// token bucket
+	tokens float64
+	last   time.Time
+
+	// sliding window
+	windowStart time.Time
+	current     int
+	previous    int
+
+	expires time.Time
+}
+
+// sweepInterval is how often the expired entries of a shard are removed
+const sweepInterval = time.Minute
+
+// NewMemoryStore = constructor for MemoryStore. shards defaults to 32 if it isn't positive
+func NewMemoryStore(shards int) *MemoryStore {
+	if shards <= 0 {
+		shards = 32
+	}
+
+	store := &MemoryStore{shards: make([]*shard, shards)}
+	for i := range store.shards {
+		store.shards[i] = &shard{entries: make(map[string]*entry)}
+	}
+	return store
+}
+
+// Take is the Store interface implementation
+func (s *MemoryStore) Take(key string, limit Limit) (Result, error) {
+	if err := limit.Validate(); err != nil {
+		return Result{}, err
+	}
+	if limit.Requests <= 0 {
+		return Result{Reset: limit.Period, RetryAfter: limit.Period}, nil
+	}
+
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	sh := s.shards[hash.Sum32()%uint32(len(s.shards))]
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if now.After(sh.nextSweep) {
+		for k, e := range sh.entries {
+			if now.After(e.expires) {
+				delete(sh.entries, k)
+			}
+		}
+		sh.nextSweep = now.Add(sweepInterval)
+	}
+
+	e, ok := sh.entries[key]
+	if !ok {
+		e = &entry{tokens: float64(limit.Requests), last: now, windowStart: now}
+		sh.entries[key] = e
+	}
+	e.expires = now.Add(2 * limit.Period)
+
+	if limit.Algorithm == SlidingWindow {
+		return e.slidingWindow(limit, now), nil
+	}
+	return e.tokenBucket(limit, now), nil
+}
+
+// tokenBucket refills the bucket for the time elapsed and takes a token if there is one
+func (e *entry) tokenBucket(limit Limit, now time.Time) Result {
+	capacity := float64(limit.Requests)
+	perToken := limit.Period / time.Duration(limit.Requests)
+
+	e.tokens = math.Min(capacity, e.tokens+float64(now.Sub(e.last))/float64(perToken))
+	e.last = now
+
+	result := Result{Limit: limit.Requests}
+	if e.tokens >= 1 {
+		e.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - e.tokens) * float64(perToken))
+	}
+
+	result.Remaining = int(e.tokens)
+	result.Reset = time.Duration((capacity - e.tokens) * float64(perToken))
+	return result
+}
+
+// slidingWindow counts the request in the current window if the weighted count allows it
+func (e *entry) slidingWindow(limit Limit, now time.Time) Result {
+	// move the windows forward, forgetting them if more than a period passed
+	if elapsed := now.Sub(e.windowStart); elapsed >= limit.Period {
+		if elapsed < 2*limit.Period {
+			e.previous = e.current
+		} else {
+			e.previous = 0
+		}
+		e.current = 0
+		e.windowStart = e.windowStart.Add(elapsed / limit.Period * limit.Period)
+	}
+
+	elapsed := now.Sub(e.windowStart)
+	overlap := 1 - float64(elapsed)/float64(limit.Period)
+	count := float64(e.previous)*overlap + float64(e.current)
+
+	result := Result{Limit: limit.Requests, Reset: limit.Period - elapsed}
+	if count+1 <= float64(limit.Requests) {
+		e.current++
+		count++
+		result.Allowed = true
+	} else {
+		// the previous window weight decreases as time passes, until the current window ends
+		result.RetryAfter = result.Reset
+		if e.previous > 0 {
+			excess := count + 1 - float64(limit.Requests)
+			wait := time.Duration(excess / float64(e.previous) * float64(limit.Period))
+			if wait < result.RetryAfter {
+				result.RetryAfter = wait
+			}
+		}
+	}
+
+	result.Remaining = int(float64(limit.Requests) - count)
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+	return result
+}
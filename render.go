@@ -0,0 +1,131 @@
+package router
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+
+	"github.com/asvins/router/errors"
+)
+
+// Content types written by the default renderer
+const (
+	ContentJSON = "application/json; charset=UTF-8"
+	ContentXML  = "text/xml; charset=UTF-8"
+	ContentText = "text/plain; charset=UTF-8"
+)
+
+// Renderer writes values on the response with the given status code.
+// *render.Render from github.com/unrolled/render implements it, so it can be used as the router Renderer
+type Renderer interface {
+	JSON(w io.Writer, status int, v interface{}) error
+	XML(w io.Writer, status int, v interface{}) error
+	Text(w io.Writer, status int, v string) error
+}
+
+// defaultRenderer implements Renderer using the standard library encoders
+type defaultRenderer struct{}
+
+// JSON - needed to implement Renderer interface
+func (defaultRenderer) JSON(w io.Writer, status int, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return write(w, status, ContentJSON, data)
+}
+
+// XML - needed to implement Renderer interface
+func (defaultRenderer) XML(w io.Writer, status int, v interface{}) error {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return write(w, status, ContentXML, data)
+}
+
+// Text - needed to implement Renderer interface
+func (defaultRenderer) Text(w io.Writer, status int, v string) error {
+	return write(w, status, ContentText, []byte(v))
+}
+
+// write sets the content type and status code when w is a http.ResponseWriter and then writes data
+func write(w io.Writer, status int, contentType string, data []byte) error {
+	if rw, ok := w.(http.ResponseWriter); ok {
+		if rw.Header().Get("Content-Type") == "" {
+			rw.Header().Set("Content-Type", contentType)
+		}
+		rw.WriteHeader(status)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// typedWriter makes a Renderer write contentType, whatever Content-Type it sets,
+// e.g. *render.Render always sets its own
+type typedWriter struct {
+	http.ResponseWriter
+	contentType string
+}
+
+// WriteHeader sets the content type before writing the status code
+func (w typedWriter) WriteHeader(status int) {
+	w.Header().Set("Content-Type", w.contentType)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write sets the content type, in case the status code wasn't written yet
+func (w typedWriter) Write(b []byte) (int, error) {
+	w.Header().Set("Content-Type", w.contentType)
+	return w.ResponseWriter.Write(b)
+}
+
+// renderer returns the router Renderer, or the default one if there is none
+func (r *Router) renderer() Renderer {
+	if r.Renderer != nil {
+		return r.Renderer
+	}
+	return defaultRenderer{}
+}
+
+// requestRenderer returns the Renderer of the router serving rq
+func requestRenderer(rq *http.Request) Renderer {
+	if rend, ok := rq.Context().Value(rendererKey).(Renderer); ok {
+		return rend
+	}
+	return defaultRenderer{}
+}
+
+// JSON writes v as a JSON with the given status code using the router Renderer.
+// It can be returned directly from a router.Handler:
+//
+//	return router.JSON(w, rq, http.StatusOK, user)
+func JSON(w http.ResponseWriter, rq *http.Request, status int, v interface{}) errors.Http {
+	if err := requestRenderer(rq).JSON(w, status, v); err != nil {
+		return errors.InternalServerError(err.Error())
+	}
+	return nil
+}
+
+// XML writes v as a XML with the given status code using the router Renderer
+func XML(w http.ResponseWriter, rq *http.Request, status int, v interface{}) errors.Http {
+	if err := requestRenderer(rq).XML(w, status, v); err != nil {
+		return errors.InternalServerError(err.Error())
+	}
+	return nil
+}
+
+// Text writes v as plain text with the given status code using the router Renderer
+func Text(w http.ResponseWriter, rq *http.Request, status int, v string) errors.Http {
+	if err := requestRenderer(rq).Text(w, status, v); err != nil {
+		return errors.InternalServerError(err.Error())
+	}
+	return nil
+}
+
+// NoContent writes an empty response with status 204
+func NoContent(w http.ResponseWriter) errors.Http {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,142 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/asvins/router/errors"
+)
+
+// RequestIDHeader is the default header the request ID is read from and echoed in
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest incoming request ID accepted, longer ones are replaced by a generated one
+const maxRequestIDLength = 128
+
+// RequestIDInterceptor reads the request ID from the request header, or generates one if there is none,
+// makes it available through RequestID and echoes it in the response.
+// It can be used both as base interceptor, usually on "/", and as Middleware to also cover unmatched requests.
+// The access logger of the logger package and the rendered errors include it
+type RequestIDInterceptor struct {
+	// Header the request ID is read from and echoed in. Defaults to X-Request-ID
+	Header string
+
+	// Generate returns a new request ID. Defaults to NewUUID, NewULID can be used for sortable IDs
+	Generate func() string
+}
+
+// NewRequestIDInterceptor = constructor for RequestIDInterceptor with the default header and generator
+func NewRequestIDInterceptor() *RequestIDInterceptor {
+	return &RequestIDInterceptor{Header: RequestIDHeader, Generate: NewUUID}
+}
+
+// Intercept is the Interceptor interface implementation.
+// The request is replaced in place, so the next interceptors and the handler see the ID even without a router
+func (i *RequestIDInterceptor) Intercept(rw http.ResponseWriter, r *http.Request) errors.Http {
+	if RequestID(r) != "" {
+		return nil
+	}
+
+	*r = *withRequestID(r, i.newID(rw, r))
+	return nil
+}
+
+// Middleware - needed to be used as router Middleware
+func (i *RequestIDInterceptor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		if RequestID(rq) == "" {
+			rq = withRequestID(rq, i.newID(w, rq))
+		}
+		next.ServeHTTP(w, rq)
+	})
+}
+
+// newID returns the valid incoming request ID or a generated one, and echoes it in the response
+func (i *RequestIDInterceptor) newID(rw http.ResponseWriter, r *http.Request) string {
+	header := i.Header
+	if header == "" {
+		header = RequestIDHeader
+	}
+
+	id := r.Header.Get(header)
+	if !validRequestID(id) {
+		generate := i.Generate
+		if generate == nil {
+			generate = NewUUID
+		}
+		id = generate()
+	}
+
+	rw.Header().Set(header, id)
+	return id
+}
+
+// withRequestID returns rq with the ID in its context, and in the router state so outer middleware see it too
+func withRequestID(rq *http.Request, id string) *http.Request {
+	if state := getState(rq); state != nil {
+		state.requestID = id
+	}
+	return rq.WithContext(context.WithValue(rq.Context(), requestIDKey, id))
+}
+
+// RequestID returns the ID of the request, or an empty string if it has none
+func RequestID(rq *http.Request) string {
+	return RequestIDFromContext(rq.Context())
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or an empty string if there is none.
+// It's meant for code that only gets the context of the request, e.g. database or outgoing HTTP calls
+func RequestIDFromContext(ctx context.Context) string {
+	if state, _ := ctx.Value(stateKey).(*requestState); state != nil && state.requestID != "" {
+		return state.requestID
+	}
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// validRequestID reports whether an incoming request ID can be used: not empty, not too long and printable ASCII only
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// NewUUID returns a random (version 4) UUID
+func NewUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// crockford is the base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID: 48 bits of millisecond timestamp and 80 random bits, so IDs sort by creation time
+func NewULID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixMilli())<<16)
+	rand.Read(b[6:])
+
+	// 128 bits in 26 characters of 5 bits, the first one only has 3
+	var out [26]byte
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
@@ -0,0 +1,79 @@
+package router
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter wraps a http.ResponseWriter recording the status code and the number of bytes written.
+// Flush and Hijack are passed to the wrapped writer
+type ResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+// NewResponseWriter = constructor for ResponseWriter
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+// WriteHeader records the status code and writes it
+func (w *ResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the number of bytes written
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Status returns the status code written, http.StatusOK if none was written yet
+func (w *ResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Size returns the number of body bytes written
+func (w *ResponseWriter) Size() int {
+	return w.size
+}
+
+// Written reports whether the status code was already written
+func (w *ResponseWriter) Written() bool {
+	return w.status != 0
+}
+
+// Flush - needed to implement http.Flusher, if the wrapped writer does
+func (w *ResponseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack - needed to implement http.Hijacker. Fails if the wrapped writer doesn't support it
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap returns the wrapped writer, used by http.ResponseController
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
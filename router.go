@@ -1,43 +1,86 @@
 package router
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"html/template"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
 
 	"github.com/asvins/router/errors"
-	"github.com/unrolled/render"
 )
 
 /*
 *	This router implements only GET POST and PUT methods.
 * interceptors can be added to specific routes or to base paths
  */
-var rend *render.Render
-
-func init() {
-	rend = render.New()
-}
 
 //HTTP METHODS
 const (
 	GET    = "GET"
+	HEAD   = "HEAD"
 	POST   = "POST"
 	PUT    = "PUT"
 	DELETE = "DELETE"
 )
 
-const (
-	servingFileRegex = "^.*\\.(html|css|js)$"
-)
-
 //Router struct containing the routes and base interceptors
 type Router struct {
 	routes           []*route
 	baseInterceptors map[string][]Interceptor
+	errorObservers   []ErrorObserver
+	statics          []*static
+	middleware       []Middleware
+	chain            http.Handler
+
+	// InterceptUnmatched makes the base interceptors also run for requests that don't match any route,
+	// so static files and not found responses go through logging, auth, etc. as well
+	InterceptUnmatched bool
+
+	// StaticFallback serves html, css and js files from the working directory when no route or static mount matches.
+	// Prefer Static to serve files from a specific directory
+	StaticFallback bool
+
+	// NotFound is called when no route matches the request and no static file is served.
+	// If nil, an errors.NotFound is returned
+	NotFound Handler
+
+	// MethodNotAllowed is called when the request path matches a route but not its method.
+	// The Allow header is already set when it's called. If nil, an errors.MethodNotAllowed is returned
+	MethodNotAllowed Handler
+
+	// ErrorRenderer writes the errors.Http returned by interceptors and handlers on the response.
+	// If nil, the error is written as JSON, XML, plain text or HTML according to the request Accept header
+	ErrorRenderer func(w http.ResponseWriter, rq *http.Request, err errors.Http)
+
+	// DefaultErrorFormat is the format errors are rendered in when the client accepts any format
+	// or none of the available ones. One of FormatJSON, FormatXML, FormatText or FormatHTML. Defaults to FormatJSON
+	DefaultErrorFormat string
+
+	// ErrorTemplate renders errors as HTML. It's executed with an ErrorView. Defaults to DefaultErrorTemplate
+	ErrorTemplate *template.Template
+
+	// ProblemDetails makes errors rendered as JSON be written as RFC 7807 application/problem+json documents.
+	// errors.ProblemStruct errors are always written as problem documents
+	ProblemDetails bool
+
+	// Messages localizes the error messages to the request Accept-Language. If nil, messages are written as they are
+	Messages *Catalog
+
+	// Debug adds the cause chain, the name of the handler or interceptor that returned the error
+	// and its stack trace to rendered errors. It has no effect in builds with the production tag
+	Debug bool
+
+	// MaxBodySize limits the size of the request bodies, in bytes. Bodies with a Content-Length over the limit
+	// get a 413 Request Entity Too Large before the handler runs, and so do the requests whose handler
+	// read past the limit and returned an error. Groups and routes can change it with BodyLimit. Zero means no limit
+	MaxBodySize int64
+
+	// Renderer is used to write errors and by the JSON, XML and Text helpers.
+	// If nil, a renderer based on the standard library encoders is used
+	Renderer Renderer
 }
 
 //NewRouter = constructor for router
@@ -50,15 +93,35 @@ type Interceptor interface {
 	Intercept(rw http.ResponseWriter, r *http.Request) errors.Http
 }
 
+// Sources of the errors given to an ErrorObserver
+const (
+	SourceBaseInterceptor  = "base_interceptor"
+	SourceRouteInterceptor = "route_interceptor"
+	SourceHandler          = "handler"
+	SourceNotFound         = "not_found"
+	SourceMethodNotAllowed = "method_not_allowed"
+	SourceMiddleware       = "middleware"
+)
+
+// ErrorObserver is notified of the errors written by the router, e.g. to report them or count them.
+// source is one of the Source constants
+type ErrorObserver func(rq *http.Request, source string, err errors.Http)
+
+// Middleware wraps the handling of every request by the router, e.g. to act after the response is written.
+// The route pattern is available through Pattern after the next handler returns
+type Middleware func(http.Handler) http.Handler
+
 // Handler defines the prototype of the custom handlers for this router
 type Handler func(http.ResponseWriter, *http.Request) errors.Http
 
 // route struct has the route path, method handler e possible specific interceptors
 type route struct {
 	method       string
+	pattern      string
 	regex        *regexp.Regexp
 	reqParams    map[int]string
 	handler      Handler
+	name         string
 	interceptors []Interceptor
 }
 
@@ -70,16 +133,66 @@ func wrap(handler http.HandlerFunc) Handler {
 	})
 }
 
-// if an error occurs, the interceptor chain will stop immediately
-func (r route) executeInterceptors(w http.ResponseWriter, rq *http.Request) errors.Http {
+// ErrorHandler converts a handler returning a plain error into a router.Handler.
+// Errors that are or wrap an errors.Http are written with their code, any other error is written as an InternalServerError
+func ErrorHandler(handler func(http.ResponseWriter, *http.Request) error) Handler {
+	return Handler(func(rw http.ResponseWriter, r *http.Request) errors.Http {
+		return errors.From(handler(rw, r))
+	})
+}
+
+// match returns the submatches of the route regex if it matches the whole path, nil otherwise
+func (r route) match(path string) []string {
+	// Example of FindStringSubmatch return:
+	//	regex:	/api/user/([^/]+)/details/([^/]+)
+	//	entered url:	/api/user/1234/details/12
+	//	return:	[/api/user/1234/details/12 1234 12]
+	matches := r.regex.FindStringSubmatch(path)
+
+	// this if is needed otherwise any url like '/api/users' would match with '/' if a route like that is registered
+	if matches == nil || matches[0] != path {
+		return nil
+	}
+	return matches
+}
+
+// if an error occurs, the interceptor chain will stop immediately and the interceptor that failed is returned with the error
+func (r route) executeInterceptors(w http.ResponseWriter, rq *http.Request) (Interceptor, errors.Http) {
 	var err errors.Http
 	for _, interceptor := range r.interceptors {
 		err = interceptor.Intercept(w, rq)
 		if err != nil {
-			return err
+			return interceptor, err
 		}
 	}
-	return nil
+	return nil, nil
+}
+
+// Methods returns the methods of the routes matching path, in the order they were added
+func (r *Router) Methods(path string) []string {
+	var methods []string
+	for _, route := range r.routes {
+		if route.match(path) != nil {
+			methods = appendMethod(methods, route.method)
+		}
+	}
+	return methods
+}
+
+// Use adds middleware wrapping every request. The first one added is the outermost
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+
+	var chain http.Handler = http.HandlerFunc(r.dispatch)
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		chain = r.middleware[i](chain)
+	}
+	r.chain = chain
+}
+
+// OnError adds an observer notified of every error written by the router, before it is rendered
+func (r *Router) OnError(observer ErrorObserver) {
+	r.errorObservers = append(r.errorObservers, observer)
 }
 
 //AddBaseInterceptor adds a new interceptor to a base path of a route
@@ -92,29 +205,34 @@ func (r *Router) AddBaseInterceptor(path string, interceptor Interceptor) {
 // If you choose to use this method, DON'T WRITE INTO THE RESPONSE WRITER IF YOU RETURN AN ERROR
 //	if you Return a router.error.Http, the router will automatically return the error as a json on the response
 func (r *Router) Handle(pattern string, method string, handler Handler, interceptors []Interceptor) {
+	r.handle(pattern, method, handler, funcName(handler), interceptors)
+}
+
+//AddRoute adds a new route using path method, handler and a variadic number of interceptors
+func (r *Router) AddRoute(pattern string, method string, handler http.HandlerFunc, interceptors ...Interceptor) {
+	r.handle(pattern, method, wrap(handler), funcName(handler), interceptors)
+}
+
+// handle adds the route if method is supported. name is the handler name used in debug mode
+func (r *Router) handle(pattern string, method string, handler Handler, name string, interceptors []Interceptor) {
 	switch method {
 	case GET:
-		r.doAddRoute(GET, pattern, handler, interceptors)
+		r.doAddRoute(GET, pattern, handler, name, interceptors)
 		break
 	case PUT:
-		r.doAddRoute(PUT, pattern, handler, interceptors)
+		r.doAddRoute(PUT, pattern, handler, name, interceptors)
 		break
 	case DELETE:
-		r.doAddRoute(DELETE, pattern, handler, interceptors)
+		r.doAddRoute(DELETE, pattern, handler, name, interceptors)
 		break
 	case POST:
-		r.doAddRoute(POST, pattern, handler, interceptors)
+		r.doAddRoute(POST, pattern, handler, name, interceptors)
 		break
 	}
 }
 
-//AddRoute adds a new route using path method, handler and a variadic number of interceptors
-func (r *Router) AddRoute(pattern string, method string, handler http.HandlerFunc, interceptors ...Interceptor) {
-	r.Handle(pattern, method, wrap(handler), interceptors)
-}
-
 //doAddRoute will add the specific route using method and string
-func (r *Router) doAddRoute(method string, pattern string, handler Handler, interceptors []Interceptor) {
+func (r *Router) doAddRoute(method string, pattern string, handler Handler, name string, interceptors []Interceptor) {
 	if !strings.HasPrefix(pattern, "/") {
 		fmt.Println("[ERROR] pattern should ALWAYS begin with '/'")
 		panic("[ERROR] pattern should ALWAYS begin with '/'")
@@ -134,8 +252,7 @@ func (r *Router) doAddRoute(method string, pattern string, handler Handler, inte
 		}
 	}
 
-	pattern = strings.Join(URISections, "/")
-	reg, err := regexp.Compile(pattern)
+	reg, err := regexp.Compile(strings.Join(URISections, "/"))
 
 	if err != nil {
 		fmt.Println("[ERROR] Unable to add requested route: ", err)
@@ -144,9 +261,11 @@ func (r *Router) doAddRoute(method string, pattern string, handler Handler, inte
 
 	route := &route{}
 	route.method = method
+	route.pattern = pattern
 	route.regex = reg
 	route.reqParams = reqParams
 	route.handler = handler
+	route.name = name
 	route.interceptors = interceptors
 
 	r.routes = append(r.routes, route)
@@ -159,18 +278,25 @@ func (r *Router) doAddRoute(method string, pattern string, handler Handler, inte
 //		ii)'/api'
 //		iii)'/api/consumer'
 //		iv)'/api/consumer/info'
-func (r *Router) executeBaseInterceptors(path string, w http.ResponseWriter, rq *http.Request) errors.Http {
+// If an error occurs, the interceptor that failed is returned with the error
+func (r *Router) executeBaseInterceptors(path string, w http.ResponseWriter, rq *http.Request) (Interceptor, errors.Http) {
+	for _, interceptor := range r.baseInterceptorsOf(path) {
+		if err := interceptor.Intercept(w, rq); err != nil {
+			return interceptor, err
+		}
+	}
+
+	return nil, nil
+}
+
+// baseInterceptorsOf returns the base interceptors of path, from the root to the full path
+func (r *Router) baseInterceptorsOf(path string) []Interceptor {
 	subpaths := strings.Split(path, "/")
-	var err errors.Http
+	var interceptors []Interceptor
 	currPath := "/"
 
 	for i := 1; i <= len(subpaths); i++ {
-		for _, interceptor := range r.baseInterceptors[currPath] {
-			err = interceptor.Intercept(w, rq)
-			if err != nil {
-				return err
-			}
-		}
+		interceptors = append(interceptors, r.baseInterceptors[currPath]...)
 		if i == len(subpaths) || subpaths[i] == "" {
 			break
 		}
@@ -181,19 +307,54 @@ func (r *Router) executeBaseInterceptors(path string, w http.ResponseWriter, rq
 		}
 	}
 
-	return nil
+	return interceptors
 }
 
-// writeError writes the errors.Http into a JSON with the correct status code.
+// WriteError writes err on the response the way the errors returned by handlers are, e.g. from a Middleware.
+// origin is the name of the middleware, shown in debug mode
+func (r *Router) WriteError(w http.ResponseWriter, rq *http.Request, err errors.Http, origin string) {
+	r.writeError(err, SourceMiddleware, origin, w, rq)
+}
+
+// writeError localizes the errors.Http message, sets the headers carried by it and writes it using the ErrorRenderer,
+// or in the format negotiated with the client if there is none.
+// source is given to the error observers and origin is the name of the handler or interceptor that returned the error,
+// shown in debug mode.
 // Return:
 //	- true if did wrote an error(err argument != nil)
 //	- false if didn't
-func writeError(err errors.Http, w http.ResponseWriter) bool {
-	if err != nil {
-		rend.JSON(w, err.Code(), err)
-		return true
+func (r *Router) writeError(err errors.Http, source string, origin string, w http.ResponseWriter, rq *http.Request) bool {
+	if err == nil {
+		return false
+	}
+	err = bodyError(rq, err)
+
+	for _, observer := range r.errorObservers {
+		observer(rq, source, err)
+	}
+
+	// message in the client language
+	if r.Messages != nil {
+		w.Header().Add("Vary", "Accept-Language")
+		var locale string
+		if err, locale = r.Messages.localize(rq.Header.Get("Accept-Language"), err); locale != "" {
+			w.Header().Set("Content-Language", locale)
+		}
+	}
+
+	// headers carried by the error, e.g. Retry-After or WWW-Authenticate
+	if headered, ok := err.(errors.Headered); ok {
+		for key, values := range headered.ErrorHeader() {
+			w.Header()[key] = values
+		}
+	}
+
+	if r.ErrorRenderer != nil {
+		r.ErrorRenderer(w, rq, err)
+	} else {
+		r.renderError(w, rq, err, r.debugInfo(err, origin))
 	}
-	return false
+	return true
 }
 
 // ServeHTTP Implements interface http.Handler
@@ -203,30 +364,41 @@ func writeError(err errors.Http, w http.ResponseWriter) bool {
 //  iii) route handler execution
 //
 //	If any of the interceptors returns an error, the interceptor chain will be stopped immediately
+//	If no route matches and InterceptUnmatched is set, base interceptors run before the method not allowed/static file/not found fallback
+//	Static files are served from the Static mounts and, if StaticFallback is set, from the working directory
+//	The middleware added with Use wrap all of it
 func (r *Router) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
-	var err errors.Http
+	// make the renderer available to the response helpers and the request state to everyone
+	ctx := context.WithValue(rq.Context(), rendererKey, r.renderer())
+	ctx = context.WithValue(ctx, stateKey, &requestState{})
+	rq = rq.WithContext(ctx)
+
+	if r.chain != nil {
+		r.chain.ServeHTTP(w, rq)
+		return
+	}
+	r.dispatch(w, rq)
+}
+
+// dispatch finds the route of the request and executes its interceptors and handler, see ServeHTTP
+func (r *Router) dispatch(w http.ResponseWriter, rq *http.Request) {
+	var allowed []string
 	requestURL := rq.URL.Path
 
 	for _, route := range r.routes {
 
-		// check method
-		if route.method != rq.Method {
-			continue
-		}
-
 		// check if regex match the request URL
-		if !route.regex.MatchString(requestURL) {
+		matches := route.match(requestURL)
+		if matches == nil {
 			continue
 		}
 
-		// Example of FindStringSubmatch return:
-		//	regex:	/api/user/([^/]+)/details/([^/]+)
-		//	entered url:	/api/user/1234/details/12
-		//	return:	[/api/user/1234/details/12 1234 12]
-		matches := route.regex.FindStringSubmatch(requestURL)
-
-		// this if is needed otherwise any url like '/api/users' would match with '/' if a route like that is registered
-		if matches[0] != requestURL {
+		// check method, GET routes also serve HEAD. The path matched, so keep track of it for a possible method not allowed response
+		if route.method != rq.Method && (route.method != GET || rq.Method != HEAD) {
+			allowed = appendMethod(allowed, route.method)
+			if route.method == GET {
+				allowed = appendMethod(allowed, HEAD)
+			}
 			continue
 		}
 
@@ -240,38 +412,87 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
 			rq.URL.RawQuery = url.Values(values).Encode()
 		}
 
+		// make the route pattern available to middleware, interceptors, handlers and error observers
+		if state := getState(rq); state != nil {
+			state.pattern = route.pattern
+		}
+
+		// router wide body limit, before the interceptors so they can change it
+		if r.MaxBodySize > 0 {
+			limitBody(w, rq, r.MaxBodySize)
+		}
+
 		// base interceptor execution
-		err = r.executeBaseInterceptors(rq.URL.Path, w, rq) //base path interceptors
-		if writeError(err, w) {
+		interceptor, err := r.executeBaseInterceptors(rq.URL.Path, w, rq) //base path interceptors
+		if r.writeError(err, SourceBaseInterceptor, interceptorName(interceptor), w, rq) {
 			return
 		}
 
 		// router interceptors execution
-		err = route.executeInterceptors(w, rq) // route specific interceptors
-		if writeError(err, w) {
+		interceptor, err = route.executeInterceptors(w, rq) // route specific interceptors
+		if r.writeError(err, SourceRouteInterceptor, interceptorName(interceptor), w, rq) {
+			return
+		}
+
+		// bodies over the limit in effect, known from their Content-Length
+		if r.writeError(checkBodySize(rq), SourceRouteInterceptor, "BodyLimit", w, rq) {
 			return
 		}
 
 		// handler execution
 		err = route.handler(w, rq) // route handler
-		if writeError(err, w) {
+		if r.writeError(err, SourceHandler, route.name, w, rq) {
 			return
 		}
 
 		return
 	}
 
-	// otherwise, serve static files? =s
-	match, matchErr := regexp.MatchString(servingFileRegex, rq.URL.Path)
+	// base interceptor execution for unmatched requests
+	if r.InterceptUnmatched {
+		interceptor, err := r.executeBaseInterceptors(rq.URL.Path, w, rq)
+		if r.writeError(err, SourceBaseInterceptor, interceptorName(interceptor), w, rq) {
+			return
+		}
+	}
 
-	if matchErr != nil {
-		log.Fatal(matchErr)
+	// the path exists, but not for this method
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		r.writeError(r.methodNotAllowed(w, rq), SourceMethodNotAllowed, "MethodNotAllowed", w, rq)
+		return
 	}
 
-	if match {
-		http.ServeFile(w, rq, rq.URL.Path[1:])
+	// otherwise, serve static files
+	if r.serveStatic(w, rq) {
 		return
 	}
 
-	http.NotFound(w, rq)
+	r.writeError(r.notFound(w, rq), SourceNotFound, "NotFound", w, rq)
+}
+
+// notFound calls the NotFound handler, or returns the default errors.NotFound if there is none
+func (r *Router) notFound(w http.ResponseWriter, rq *http.Request) errors.Http {
+	if r.NotFound != nil {
+		return r.NotFound(w, rq)
+	}
+	return errors.NotFound("404 page not found")
+}
+
+// methodNotAllowed calls the MethodNotAllowed handler, or returns the default errors.MethodNotAllowed if there is none
+func (r *Router) methodNotAllowed(w http.ResponseWriter, rq *http.Request) errors.Http {
+	if r.MethodNotAllowed != nil {
+		return r.MethodNotAllowed(w, rq)
+	}
+	return errors.MethodNotAllowed("405 method not allowed")
+}
+
+// appendMethod appends method to methods if it isn't there yet
+func appendMethod(methods []string, method string) []string {
+	for _, m := range methods {
+		if m == method {
+			return methods
+		}
+	}
+	return append(methods, method)
 }
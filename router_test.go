@@ -1,13 +1,19 @@
 package router
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path"
 	"strconv"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	routerErrors "github.com/asvins/router/errors"
@@ -524,3 +530,967 @@ func TestAddingMissConstructedRoute(t *testing.T) {
 		return nil
 	}, []Interceptor{})
 }
+
+type countInterceptor struct {
+	count int
+}
+
+func (c *countInterceptor) Intercept(rw http.ResponseWriter, r *http.Request) routerErrors.Http {
+	c.count++
+	return nil
+}
+
+func TestInterceptUnmatched(t *testing.T) {
+	fmt.Println("-- TestInterceptUnmatched start --")
+
+	counter := &countInterceptor{}
+	router := NewRouter()
+	router.AddBaseInterceptor("/api", counter)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/api/missing", nil))
+	if counter.count != 0 {
+		t.Error("Base interceptors should not run for unmatched requests by default. Got", counter.count)
+	}
+
+	router.InterceptUnmatched = true
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/api/missing", nil))
+	if counter.count != 1 {
+		t.Error("Base interceptors should run for unmatched requests when InterceptUnmatched is set. Got", counter.count)
+	}
+
+	if w.Code != http.StatusNotFound {
+		t.Error("Status Code should be", http.StatusNotFound, " Got", w.Code)
+	}
+
+	fmt.Println("-- TestInterceptUnmatched end --")
+}
+
+func TestNotFoundAndMethodNotAllowed(t *testing.T) {
+	fmt.Println("-- TestNotFoundAndMethodNotAllowed start --")
+
+	router := NewRouter()
+	router.AddRoute("/api/orders", GET, func(w http.ResponseWriter, rq *http.Request) {})
+	router.AddRoute("/api/orders", POST, func(w http.ResponseWriter, rq *http.Request) {})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/api/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Error("Status Code should be", http.StatusNotFound, " Got", w.Code)
+	}
+
+	if !strings.Contains(w.Body.String(), `"message"`) {
+		t.Error("Default not found should be rendered as an errors.Http. Got", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(DELETE, "/api/orders", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Error("Status Code should be", http.StatusMethodNotAllowed, " Got", w.Code)
+	}
+
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD, POST" {
+		t.Error("Allow header should be 'GET, HEAD, POST' Got", allow)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(HEAD, "/api/orders", nil))
+	if w.Code != http.StatusOK {
+		t.Error("HEAD should be served by the GET route. Got", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(HEAD, "/api/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Error("HEAD Status Code should be", http.StatusNotFound, " Got", w.Code)
+	}
+
+	rendered := 0
+	router.NotFound = func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return routerErrors.BadRequest("custom not found")
+	}
+	router.ErrorRenderer = func(w http.ResponseWriter, rq *http.Request, err routerErrors.Http) {
+		rendered++
+		w.WriteHeader(err.Code())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/api/missing", nil))
+	if w.Code != http.StatusBadRequest || rendered != 1 {
+		t.Error("Custom NotFound and ErrorRenderer should be used. Got", w.Code, rendered)
+	}
+
+	fmt.Println("-- TestNotFoundAndMethodNotAllowed end --")
+}
+
+type upperRenderer struct {
+	defaultRenderer
+}
+
+func (u upperRenderer) Text(w io.Writer, status int, v string) error {
+	return u.defaultRenderer.Text(w, status, strings.ToUpper(v))
+}
+
+func TestResponseHelpers(t *testing.T) {
+	fmt.Println("-- TestResponseHelpers start --")
+
+	router := NewRouter()
+	router.Handle("/json", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return JSON(w, rq, http.StatusCreated, map[string]string{"name": "asvins"})
+	}, []Interceptor{})
+	router.Handle("/text", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return Text(w, rq, http.StatusOK, "hello")
+	}, []Interceptor{})
+	router.Handle("/empty", DELETE, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return NoContent(w)
+	}, []Interceptor{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/json", nil))
+	if w.Code != http.StatusCreated || w.Body.String() != `{"name":"asvins"}` {
+		t.Error("Unexpected JSON response. Got", w.Code, w.Body.String())
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != ContentJSON {
+		t.Error("Content-Type should be", ContentJSON, " Got", ct)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(DELETE, "/empty", nil))
+	if w.Code != http.StatusNoContent {
+		t.Error("Status Code should be", http.StatusNoContent, " Got", w.Code)
+	}
+
+	router.Renderer = upperRenderer{}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/text", nil))
+	if w.Body.String() != "HELLO" {
+		t.Error("Router Renderer should be used by the helpers. Got", w.Body.String())
+	}
+
+	fmt.Println("-- TestResponseHelpers end --")
+}
+
+func TestErrorContentNegotiation(t *testing.T) {
+	fmt.Println("-- TestErrorContentNegotiation start --")
+
+	router := NewRouter()
+	router.Handle("/fail", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return routerErrors.BadRequest("bad <request>")
+	}, []Interceptor{})
+
+	cases := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", FormatJSON, `{"message":"bad \u003crequest\u003e"}`},
+		{"*/*", FormatJSON, `{"message":"bad \u003crequest\u003e"}`},
+		{"application/xml", FormatXML, `<error><code>400</code><message>bad &lt;request&gt;</message></error>`},
+		{"text/plain", FormatText, "bad <request>"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", FormatHTML, "bad &lt;request&gt;"},
+		{"application/json;q=0.5, text/plain", FormatText, "bad <request>"},
+		{"image/png", FormatJSON, `{"message":"bad \u003crequest\u003e"}`},
+	}
+
+	for _, c := range cases {
+		rq := httptest.NewRequest(GET, "/fail", nil)
+		rq.Header.Set("Accept", c.accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, rq)
+
+		if w.Code != http.StatusBadRequest {
+			t.Error("Status Code should be", http.StatusBadRequest, " Got", w.Code)
+		}
+
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, c.contentType) {
+			t.Error("Accept:", c.accept, "Content-Type should be", c.contentType, " Got", ct)
+		}
+
+		if !strings.Contains(w.Body.String(), c.body) {
+			t.Error("Accept:", c.accept, "Body should contain", c.body, " Got", w.Body.String())
+		}
+
+		if w.Header().Get("Vary") != "Accept" {
+			t.Error("Vary header should be Accept. Got", w.Header().Get("Vary"))
+		}
+	}
+
+	router.DefaultErrorFormat = FormatText
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/fail", nil))
+	if w.Body.String() != "bad <request>" {
+		t.Error("DefaultErrorFormat should be used when there is no Accept header. Got", w.Body.String())
+	}
+
+	fmt.Println("-- TestErrorContentNegotiation end --")
+}
+
+type overridingRenderer struct {
+	defaultRenderer
+}
+
+func (o overridingRenderer) JSON(w io.Writer, status int, v interface{}) error {
+	w.(http.ResponseWriter).Header().Set("Content-Type", ContentJSON)
+	return o.defaultRenderer.JSON(w, status, v)
+}
+
+func (o overridingRenderer) XML(w io.Writer, status int, v interface{}) error {
+	w.(http.ResponseWriter).Header().Set("Content-Type", ContentXML)
+	return o.defaultRenderer.XML(w, status, v)
+}
+
+func TestProblemDetails(t *testing.T) {
+	fmt.Println("-- TestProblemDetails start --")
+
+	router := NewRouter()
+	router.Handle("/problem", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return routerErrors.Problem(http.StatusConflict).
+			WithType("https://example.com/probs/out-of-stock").
+			WithDetail("Item 42 is out of stock").
+			With("item", 42)
+	}, []Interceptor{})
+	router.Handle("/unauthorized", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return routerErrors.Unauthorized("You shall not pass")
+	}, []Interceptor{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/problem", nil))
+	expected := `{"detail":"Item 42 is out of stock","instance":"/problem","item":42,"status":409,"title":"Conflict","type":"https://example.com/probs/out-of-stock"}`
+	if w.Code != http.StatusConflict || w.Body.String() != expected {
+		t.Error("Unexpected problem response. Got", w.Code, w.Body.String())
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != FormatProblemJSON {
+		t.Error("Content-Type should be", FormatProblemJSON, " Got", ct)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/unauthorized", nil))
+	if w.Body.String() != `{"message":"You shall not pass"}` {
+		t.Error("Errors should keep their JSON shape by default. Got", w.Body.String())
+	}
+
+	router.ProblemDetails = true
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/unauthorized", nil))
+	expected = `{"detail":"You shall not pass","instance":"/unauthorized","status":401,"title":"Unauthorized","type":"about:blank"}`
+	if w.Body.String() != expected {
+		t.Error("Errors should be mapped to problem documents. Got", w.Body.String())
+	}
+
+	// renderers like unrolled/render always set their own Content-Type
+	router.Renderer = overridingRenderer{}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/problem", nil))
+	if ct := w.Header().Get("Content-Type"); ct != FormatProblemJSON {
+		t.Error("Content-Type with a custom renderer should be", FormatProblemJSON, " Got", ct)
+	}
+
+	rq := httptest.NewRequest(GET, "/unauthorized", nil)
+	rq.Header.Set("Accept", FormatXML)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, rq)
+	if ct := w.Header().Get("Content-Type"); ct != FormatXML+"; charset=UTF-8" {
+		t.Error("XML Content-Type with a custom renderer should be", FormatXML, " Got", ct)
+	}
+
+	fmt.Println("-- TestProblemDetails end --")
+}
+
+func TestErrorCodes(t *testing.T) {
+	fmt.Println("-- TestErrorCodes start --")
+
+	router := NewRouter()
+	router.Handle("/teapot", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return routerErrors.New(http.StatusTeapot, "I'm a teapot")
+	}, []Interceptor{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/teapot", nil))
+	if w.Code != http.StatusTeapot || w.Body.String() != `{"message":"I'm a teapot"}` {
+		t.Error("Unexpected response for errors.New. Got", w.Code, w.Body.String())
+	}
+
+	if routerErrors.NotFound("x") != routerErrors.NotFound("x") || (routerErrors.BadRequestStruct{Msg: "x"}) != routerErrors.BadRequest("x") {
+		t.Error("The original error structs should stay comparable")
+	}
+
+	err := fmt.Errorf("loading order: %w", routerErrors.Gone("order was deleted"))
+	if !errors.Is(err, routerErrors.Gone("")) || errors.Is(err, routerErrors.NotFound("")) {
+		t.Error("errors.Is should match errors with the same status code")
+	}
+
+	if !routerErrors.HasCode(err, http.StatusGone) {
+		t.Error("HasCode should find the wrapped status code")
+	}
+
+	if routerErrors.ServiceUnavailable("").Code() != http.StatusServiceUnavailable {
+		t.Error("ServiceUnavailable should have status", http.StatusServiceUnavailable)
+	}
+
+	fmt.Println("-- TestErrorCodes end --")
+}
+
+func TestErrorHandler(t *testing.T) {
+	fmt.Println("-- TestErrorHandler start --")
+
+	cause := errors.New("connection refused")
+	router := NewRouter()
+	router.Handle("/plain", GET, ErrorHandler(func(w http.ResponseWriter, rq *http.Request) error {
+		return cause
+	}), []Interceptor{})
+	router.Handle("/wrapped", GET, ErrorHandler(func(w http.ResponseWriter, rq *http.Request) error {
+		return fmt.Errorf("finding user: %w", routerErrors.NotFound("user not found"))
+	}), []Interceptor{})
+	router.Handle("/ok", GET, ErrorHandler(func(w http.ResponseWriter, rq *http.Request) error {
+		return nil
+	}), []Interceptor{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/plain", nil))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), cause.Error()) {
+		t.Error("Plain errors should be a 500 without the cause. Got", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/wrapped", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"message":"user not found"}` {
+		t.Error("Wrapped errors.Http should keep their code. Got", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/ok", nil))
+	if w.Code != http.StatusOK {
+		t.Error("Status Code should be", http.StatusOK, " Got", w.Code)
+	}
+
+	wrapped := routerErrors.Wrap(cause, http.StatusBadGateway, "upstream failed")
+	if !errors.Is(wrapped, cause) || !strings.Contains(wrapped.Error(), cause.Error()) {
+		t.Error("Wrap should expose the cause to Unwrap and Error. Got", wrapped.Error())
+	}
+
+	fmt.Println("-- TestErrorHandler end --")
+}
+
+func TestValidationError(t *testing.T) {
+	fmt.Println("-- TestValidationError start --")
+
+	router := NewRouter()
+	router.Handle("/users", POST, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		v := routerErrors.Validation("invalid user")
+		v.Add("email", "required", "email is required")
+		v.Add("age", "min", "age must be at least 18")
+		return v.Err()
+	}, []Interceptor{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(POST, "/users", nil))
+	expected := `{"message":"invalid user","fields":[{"field":"email","code":"required","message":"email is required"},{"field":"age","code":"min","message":"age must be at least 18"}]}`
+	if w.Code != http.StatusUnprocessableEntity || w.Body.String() != expected {
+		t.Error("Unexpected validation response. Got", w.Code, w.Body.String())
+	}
+
+	rq := httptest.NewRequest(POST, "/users", nil)
+	rq.Header.Set("Accept", "text/plain")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, rq)
+	if w.Body.String() != "invalid user\nemail: email is required\nage: age must be at least 18" {
+		t.Error("Unexpected plain text validation response. Got", w.Body.String())
+	}
+
+	if routerErrors.Validation("nothing wrong").Err() != nil {
+		t.Error("Err should be nil without field errors")
+	}
+
+	fmt.Println("-- TestValidationError end --")
+}
+
+func TestErrorMetadata(t *testing.T) {
+	fmt.Println("-- TestErrorMetadata start --")
+
+	router := NewRouter()
+	router.Handle("/user", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return routerErrors.NotFound("user not found").WithCode("USER_NOT_FOUND").WithDetail("id", 42)
+	}, []Interceptor{})
+	router.Handle("/slow", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return routerErrors.TooManyRequests("slow down").WithHeader("Retry-After", "30")
+	}, []Interceptor{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/user", nil))
+	expected := `{"message":"user not found","code":"USER_NOT_FOUND","details":{"id":42}}`
+	if w.Code != http.StatusNotFound || w.Body.String() != expected {
+		t.Error("Unexpected response for error with metadata. Got", w.Code, w.Body.String())
+	}
+
+	router.Handle("/order", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return routerErrors.Conflict("order locked").WithDetail("id", 42).WithDetail("lock", map[string]interface{}{"owner": "alice"})
+	}, []Interceptor{})
+	rq := httptest.NewRequest(GET, "/order", nil)
+	rq.Header.Set("Accept", FormatXML)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, rq)
+	expected = `<error><code>409</code><message>order locked</message><details><detail key="id">42</detail><detail key="lock"><detail key="owner">alice</detail></detail></details></error>`
+	if w.Body.String() != expected {
+		t.Error("XML errors should have the details. Got", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/slow", nil))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Error("Error headers should be set on the response. Got", w.Code, w.Header())
+	}
+
+	fmt.Println("-- TestErrorMetadata end --")
+}
+
+// quotaError is an errors.Http of the application, not localizable
+type quotaError struct {
+	Msg   string `json:"message"`
+	Limit int    `json:"limit"`
+}
+
+func (e quotaError) Message() string { return e.Msg }
+func (e quotaError) Code() int       { return http.StatusTooManyRequests }
+func (e quotaError) Error() string   { return e.Msg }
+
+func TestLocalizedErrors(t *testing.T) {
+	fmt.Println("-- TestLocalizedErrors start --")
+
+	dir := t.TempDir()
+	ioutil.WriteFile(dir+"/pt-BR.json", []byte(`{"USER_NOT_FOUND": "Usuário {{.id}} não encontrado", "required": "{{.field}} é obrigatório"}`), 0644)
+	ioutil.WriteFile(dir+"/en.json", []byte(`{"USER_NOT_FOUND": "User {{.id}} not found", "404": "Not here", "429": "Too many requests"}`), 0644)
+
+	catalog := NewCatalog("en")
+	if err := catalog.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter()
+	router.Messages = catalog
+	router.Handle("/user", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return routerErrors.NotFound("user not found").WithCode("USER_NOT_FOUND").WithDetail("id", 42)
+	}, []Interceptor{})
+	router.Handle("/user/unknown", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return routerErrors.NotFound("user not found").WithCode("USER_NOT_FOUND")
+	}, []Interceptor{})
+	router.Handle("/users", POST, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		v := routerErrors.Validation("invalid user")
+		v.Add("email", "required", "email is required")
+		return v.Err()
+	}, []Interceptor{})
+	router.Handle("/users/pointer", POST, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		v := &routerErrors.ValidationStruct{Status: http.StatusUnprocessableEntity, Msg: "invalid user"}
+		v.Add("email", "required", "email is required")
+		return v
+	}, []Interceptor{})
+	router.Handle("/quota", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return quotaError{Msg: "quota exceeded", Limit: 10}
+	}, []Interceptor{})
+
+	cases := []struct {
+		method   string
+		path     string
+		language string
+		locale   string
+		body     string
+	}{
+		{GET, "/user", "pt-BR,pt;q=0.9", "pt-BR", `"Usuário 42 não encontrado"`},
+		{GET, "/user", "fr", "en", `"User 42 not found"`},
+		{GET, "/missing", "pt", "en", `"Not here"`},
+		{POST, "/users", "pt-PT", "pt-BR", `"email é obrigatório"`},
+		{GET, "/user/unknown", "pt-BR", "", `"user not found"`},
+		{POST, "/users/pointer", "pt-BR", "pt-BR", `"email é obrigatório"`},
+		{GET, "/quota", "en", "", `{"message":"quota exceeded","limit":10}`},
+	}
+
+	for _, c := range cases {
+		rq := httptest.NewRequest(c.method, c.path, nil)
+		rq.Header.Set("Accept-Language", c.language)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, rq)
+
+		if !strings.Contains(w.Body.String(), c.body) {
+			t.Error("Accept-Language:", c.language, "Body should contain", c.body, " Got", w.Body.String())
+		}
+
+		if w.Header().Get("Content-Language") != c.locale {
+			t.Error("Accept-Language:", c.language, "Content-Language should be", c.locale, " Got", w.Header().Get("Content-Language"))
+		}
+	}
+
+	// the main message and the field messages come from different locales
+	mixed := NewCatalog("en")
+	mixed.Add("en", map[string]string{"422": "Invalid data"})
+	mixed.Add("pt-BR", map[string]string{"required": "{{.field}} é obrigatório"})
+	router.Messages = mixed
+
+	rq := httptest.NewRequest(POST, "/users", nil)
+	rq.Header.Set("Accept-Language", "pt-BR")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, rq)
+	if w.Header().Get("Content-Language") != "en" || !strings.Contains(w.Body.String(), `"Invalid data"`) {
+		t.Error("Content-Language should be the locale of the main message. Got", w.Header().Get("Content-Language"), w.Body.String())
+	}
+
+	fmt.Println("-- TestLocalizedErrors end --")
+}
+
+func TestDebugMode(t *testing.T) {
+	fmt.Println("-- TestDebugMode start --")
+	if !debugBuild {
+		t.Skip("debug mode is disabled in production builds")
+	}
+
+	router := NewRouter()
+	router.Handle("/fail", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		cause := fmt.Errorf("loading orders: %w", errors.New("connection refused"))
+		return routerErrors.Wrap(cause, http.StatusInternalServerError, "could not load orders")
+	}, []Interceptor{})
+	router.AddRoute("/intercepted", GET, func(w http.ResponseWriter, rq *http.Request) {}, &authInterceptor{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/fail", nil))
+	if strings.Contains(w.Body.String(), "debug") {
+		t.Error("Debug information should not be rendered by default. Got", w.Body.String())
+	}
+
+	router.Debug = true
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/fail", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, `"causes":["loading orders: connection refused","connection refused"]`) {
+		t.Error("Debug information should have the cause chain. Got", body)
+	}
+
+	if !strings.Contains(body, `"origin":"github.com/asvins/router.TestDebugMode.func1"`) {
+		t.Error("Debug information should have the handler name. Got", body)
+	}
+
+	if !strings.Contains(body, `"stack":["github.com/asvins/router.TestDebugMode.func1`) {
+		t.Error("Debug information should have the stack trace. Got", body)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/intercepted", nil))
+	if !strings.Contains(w.Body.String(), `"origin":"*router.authInterceptor"`) {
+		t.Error("Debug information should have the interceptor name. Got", w.Body.String())
+	}
+
+	fmt.Println("-- TestDebugMode end --")
+}
+
+type authInterceptor struct{}
+
+func (a authInterceptor) Intercept(rw http.ResponseWriter, r *http.Request) routerErrors.Http {
+	return routerErrors.Unauthorized("no token")
+}
+
+func TestOnError(t *testing.T) {
+	fmt.Println("-- TestOnError start --")
+
+	router := NewRouter()
+	router.AddBaseInterceptor("/admin", &authInterceptor{})
+	router.AddRoute("/admin/users", GET, func(w http.ResponseWriter, rq *http.Request) {})
+	router.AddRoute("/orders", GET, func(w http.ResponseWriter, rq *http.Request) {}, &authInterceptor{})
+	router.Handle("/orders/:id", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		return routerErrors.NotFound("order not found")
+	}, []Interceptor{})
+
+	var observed []string
+	router.OnError(func(rq *http.Request, source string, err routerErrors.Http) {
+		observed = append(observed, fmt.Sprint(source, " ", Pattern(rq), " ", err.Code()))
+	})
+
+	for _, path := range []string{"/admin/users", "/orders", "/orders/42", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(GET, path, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(POST, "/orders", nil))
+
+	expected := []string{
+		"base_interceptor /admin/users 401",
+		"route_interceptor /orders 401",
+		"handler /orders/:id 404",
+		"not_found  404",
+		"method_not_allowed  405",
+	}
+	if strings.Join(observed, ",") != strings.Join(expected, ",") {
+		t.Error("Unexpected observed errors. Got", observed)
+	}
+
+	fmt.Println("-- TestOnError end --")
+}
+
+func TestStatic(t *testing.T) {
+	fmt.Println("-- TestStatic start --")
+
+	root := fstest.MapFS{
+		"index.html":      {Data: []byte("<h1>home</h1>")},
+		"app.js":          {Data: []byte("console.log('app')")},
+		"app.map":         {Data: []byte("{}")},
+		"data.custom":     {Data: []byte("custom")},
+		".env":            {Data: []byte("SECRET=1")},
+		"docs/guide.html": {Data: []byte("guide")},
+	}
+
+	router := NewRouter()
+	router.Static("/assets", root, StaticOptions{
+		Extensions:      []string{".html", ".js", ".custom"},
+		MIMETypes:       map[string]string{".custom": "application/x-custom"},
+		ListDirectories: true,
+	})
+
+	cases := []struct {
+		path   string
+		code   int
+		header string
+		body   string
+	}{
+		{"/assets/app.js", http.StatusOK, "text/javascript; charset=utf-8", "console.log('app')"},
+		{"/assets/", http.StatusOK, "text/html; charset=utf-8", "<h1>home</h1>"},
+		{"/assets", http.StatusMovedPermanently, "", ""},
+		{"/assets/data.custom", http.StatusOK, "application/x-custom", "custom"},
+		{"/assets/docs/", http.StatusOK, "text/html; charset=UTF-8", `<a href="guide.html">`},
+		{"/assets/app.map", http.StatusNotFound, "", ""},
+		{"/assets/.env", http.StatusNotFound, "", ""},
+		{"/assets/../router.go", http.StatusNotFound, "", ""},
+		{"/assets/missing.js", http.StatusNotFound, "", ""},
+		{"/app.js", http.StatusNotFound, "", ""},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(GET, c.path, nil))
+
+		if w.Code != c.code {
+			t.Error(c.path, "Status Code should be", c.code, " Got", w.Code)
+		}
+
+		if c.header != "" && w.Header().Get("Content-Type") != c.header {
+			t.Error(c.path, "Content-Type should be", c.header, " Got", w.Header().Get("Content-Type"))
+		}
+
+		if !strings.Contains(w.Body.String(), c.body) {
+			t.Error(c.path, "Body should contain", c.body, " Got", w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/assets/docs?v=1", nil))
+	if w.Header().Get("Location") != "docs/?v=1" {
+		t.Error("Directories should redirect relatively with the trailing slash. Got", w.Header().Get("Location"))
+	}
+
+	root["evil.com/index.html"] = &fstest.MapFile{Data: []byte("evil")}
+	router = NewRouter()
+	router.Static("/", root)
+	rq := httptest.NewRequest(GET, "/", nil)
+	rq.URL.Path = "//evil.com"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, rq)
+	if location := w.Header().Get("Location"); w.Code != http.StatusMovedPermanently || strings.HasPrefix(location, "//") {
+		t.Error("Redirects should not point to another host. Got", w.Code, location)
+	}
+
+	// symlinks leaving the directory of StaticDir are not followed
+	dir, secret := t.TempDir(), t.TempDir()
+	ioutil.WriteFile(dir+"/app.js", []byte("app"), 0644)
+	ioutil.WriteFile(secret+"/passwd", []byte("root"), 0644)
+	if err := os.Symlink(secret+"/passwd", dir+"/passwd"); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	router = NewRouter()
+	router.StaticDir("/", dir)
+	for path, code := range map[string]int{"/app.js": http.StatusOK, "/passwd": http.StatusNotFound} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(GET, path, nil))
+		if w.Code != code {
+			t.Error(path, "Status Code should be", code, " Got", w.Code)
+		}
+	}
+
+	fmt.Println("-- TestStatic end --")
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	fmt.Println("-- TestStaticPrecompressed start --")
+
+	root := fstest.MapFS{
+		"app.js":           {Data: []byte("plain")},
+		"app.js.gz":        {Data: []byte("gzipped")},
+		"app.js.br":        {Data: []byte("brotli")},
+		"app.3f2a9c1b.css": {Data: []byte("body{}")},
+	}
+
+	router := NewRouter()
+	router.Static("/", root, StaticOptions{Precompressed: true, ETag: true, FingerprintMaxAge: 365 * 24 * time.Hour})
+
+	cases := []struct {
+		path           string
+		acceptEncoding string
+		encoding       string
+		body           string
+	}{
+		{"/app.js", "", "", "plain"},
+		{"/app.js", "gzip, deflate", "gzip", "gzipped"},
+		{"/app.js", "gzip, br", "br", "brotli"},
+		{"/app.js", "br;q=0.5, gzip", "gzip", "gzipped"},
+		{"/app.3f2a9c1b.css", "br", "", "body{}"},
+	}
+
+	for _, c := range cases {
+		rq := httptest.NewRequest(GET, c.path, nil)
+		rq.Header.Set("Accept-Encoding", c.acceptEncoding)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, rq)
+
+		if w.Body.String() != c.body || w.Header().Get("Content-Encoding") != c.encoding {
+			t.Error(c.path, c.acceptEncoding, "Unexpected response. Got", w.Header().Get("Content-Encoding"), w.Body.String())
+		}
+
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), mime.TypeByExtension(path.Ext(c.path))) {
+			t.Error(c.path, "Content-Type should be the one of the requested file. Got", w.Header().Get("Content-Type"))
+		}
+
+		if w.Header().Get("Vary") != "Accept-Encoding" || w.Header().Get("ETag") == "" {
+			t.Error(c.path, "Vary and ETag should be set. Got", w.Header())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/app.3f2a9c1b.css", nil))
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=31536000, immutable" {
+		t.Error("Fingerprinted files should have a long-lived Cache-Control. Got", cc)
+	}
+
+	rq := httptest.NewRequest(GET, "/app.js", nil)
+	rq.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, rq)
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "" {
+		t.Error("Different files should have different ETags and not fingerprinted ones no Cache-Control. Got", w.Code, w.Header())
+	}
+
+	rq = httptest.NewRequest(GET, "/app.js", nil)
+	rq.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, rq)
+	if w.Code != http.StatusNotModified {
+		t.Error("Status Code should be", http.StatusNotModified, " Got", w.Code)
+	}
+
+	fmt.Println("-- TestStaticPrecompressed end --")
+}
+
+func TestStaticSPAFallback(t *testing.T) {
+	fmt.Println("-- TestStaticSPAFallback start --")
+
+	root := fstest.MapFS{
+		"index.html": {Data: []byte("<div id=app></div>")},
+		"app.js":     {Data: []byte("render()")},
+	}
+
+	router := NewRouter()
+	router.Static("/", root, StaticOptions{Fallback: "index.html", FallbackExclude: []string{"/api"}})
+	router.AddRoute("/api/orders", GET, func(w http.ResponseWriter, rq *http.Request) {
+		fmt.Fprint(w, "orders")
+	})
+
+	browser := "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	cases := []struct {
+		path   string
+		accept string
+		code   int
+		body   string
+	}{
+		{"/app/orders/42", browser, http.StatusOK, "<div id=app></div>"},
+		{"/app.js", "*/*", http.StatusOK, "render()"},
+		{"/missing.js", browser, http.StatusNotFound, ""},
+		{"/app/orders/42", "application/json", http.StatusNotFound, ""},
+		{"/api/orders", browser, http.StatusOK, "orders"},
+		{"/api/missing", browser, http.StatusNotFound, ""},
+	}
+
+	for _, c := range cases {
+		rq := httptest.NewRequest(GET, c.path, nil)
+		rq.Header.Set("Accept", c.accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, rq)
+
+		if w.Code != c.code || !strings.Contains(w.Body.String(), c.body) {
+			t.Error(c.path, c.accept, "Unexpected response. Got", w.Code, w.Body.String())
+		}
+	}
+
+	fmt.Println("-- TestStaticSPAFallback end --")
+}
+
+func TestRequestID(t *testing.T) {
+	fmt.Println("-- TestRequestID start --")
+
+	router := NewRouter()
+	router.Use(NewRequestIDInterceptor().Middleware)
+	router.Handle("/id", GET, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		if RequestIDFromContext(rq.Context()) != RequestID(rq) {
+			t.Error("The request ID should be in the request context. Got", RequestIDFromContext(rq.Context()))
+		}
+		return Text(w, rq, http.StatusOK, RequestID(rq))
+	}, []Interceptor{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(GET, "/id", nil))
+	id := w.Header().Get(RequestIDHeader)
+	if len(id) != 36 || id[14] != '4' || w.Body.String() != id {
+		t.Error("A UUIDv4 should be generated, echoed and available to the handler. Got", id, w.Body.String())
+	}
+
+	rq := httptest.NewRequest(GET, "/missing", nil)
+	rq.Header.Set(RequestIDHeader, "abc-123")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, rq)
+	if w.Header().Get(RequestIDHeader) != "abc-123" || !strings.Contains(w.Body.String(), `"request_id":"abc-123"`) {
+		t.Error("The incoming request ID should be used and rendered in errors. Got", w.Header(), w.Body.String())
+	}
+
+	// without a router, the ID is stored in the request context
+	rq = httptest.NewRequest(GET, "/id", nil)
+	rq.Header.Set(RequestIDHeader, "def-456")
+	NewRequestIDInterceptor().Intercept(httptest.NewRecorder(), rq)
+	if RequestIDFromContext(rq.Context()) != "def-456" || RequestID(rq) != "def-456" {
+		t.Error("Intercept should store the request ID without a router. Got", RequestIDFromContext(rq.Context()))
+	}
+
+	ulid := NewULID()
+	if len(ulid) != 26 || ulid[:10] > NewULID()[:10] {
+		t.Error("ULIDs should have 26 characters and sort by time. Got", ulid)
+	}
+
+	fmt.Println("-- TestRequestID end --")
+}
+
+type describedInterceptor struct {
+	requirements []string
+}
+
+func (d describedInterceptor) Intercept(rw http.ResponseWriter, r *http.Request) routerErrors.Http {
+	return nil
+}
+
+func (d describedInterceptor) Requirements() []string {
+	return d.requirements
+}
+
+func updateOrder(w http.ResponseWriter, rq *http.Request) {}
+
+func TestRoutes(t *testing.T) {
+	fmt.Println("-- TestRoutes start --")
+
+	router := NewRouter()
+	router.AddBaseInterceptor("/api", describedInterceptor{[]string{"authenticated"}})
+	router.AddRoute("/api/orders/:id", PUT, updateOrder, describedInterceptor{[]string{"scope:orders:write"}}, &countInterceptor{})
+	router.AddRoute("/health", GET, updateOrder)
+
+	routes := router.Routes()
+	if len(routes) != 2 {
+		t.Fatal("Routes should list 2 routes Got", len(routes))
+	}
+
+	orders := routes[0]
+	if orders.Method != PUT || orders.Pattern != "/api/orders/:id" || !strings.HasSuffix(orders.Handler, ".updateOrder") {
+		t.Error("Route should be PUT /api/orders/:id with its handler Got", orders.Method, orders.Pattern, orders.Handler)
+	}
+	if strings.Join(orders.Requirements, ",") != "authenticated,scope:orders:write" {
+		t.Error("Requirements should be the base and route ones Got", orders.Requirements)
+	}
+	if len(orders.Interceptors) != 3 || orders.Interceptors[2] != "*router.countInterceptor" {
+		t.Error("Interceptors should be listed in order Got", orders.Interceptors)
+	}
+	if routes[1].Requirements != nil || routes[1].Interceptors != nil {
+		t.Error("Route without interceptors should have no requirements Got", routes[1].Requirements)
+	}
+
+	fmt.Println("-- TestRoutes end --")
+}
+
+func TestBodyLimits(t *testing.T) {
+	fmt.Println("-- TestBodyLimits start --")
+
+	readBody := ErrorHandler(func(w http.ResponseWriter, rq *http.Request) error {
+		body, err := io.ReadAll(rq.Body)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(body)
+		return err
+	})
+
+	router := NewRouter()
+	router.MaxBodySize = 8
+	router.AddBaseInterceptor("/api", BodyLimit(16))
+	router.Handle("/small", POST, readBody, []Interceptor{})
+	router.Handle("/api/orders", POST, readBody, []Interceptor{ContentTypes{"application/json", "text/*"}})
+	router.Handle("/api/uploads", POST, readBody, []Interceptor{BodyLimit(0)})
+
+	// the read error isn't returned as it is
+	reached := 0
+	router.Handle("/plain", POST, func(w http.ResponseWriter, rq *http.Request) routerErrors.Http {
+		reached++
+		if _, err := io.ReadAll(rq.Body); err != nil {
+			return routerErrors.BadRequest(err.Error())
+		}
+		return nil
+	}, []Interceptor{})
+
+	post := func(path, contentType string, body io.Reader) *httptest.ResponseRecorder {
+		rq := httptest.NewRequest(POST, path, body)
+		rq.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, rq)
+		return w
+	}
+
+	// hides the Content-Length so the limit is hit while reading
+	type reader struct{ io.Reader }
+	cases := []struct {
+		path, contentType, body string
+		chunked                 bool
+		code                    int
+	}{
+		{"/small", "text/plain", "12345678", false, http.StatusOK},
+		{"/small", "text/plain", "123456789", false, http.StatusRequestEntityTooLarge},
+		{"/small", "text/plain", "123456789", true, http.StatusRequestEntityTooLarge},
+		{"/api/orders", "application/json; charset=utf-8", `{"id": 1234567}`, true, http.StatusOK},
+		{"/api/orders", "application/json", `{"id": 123456789}`, true, http.StatusRequestEntityTooLarge},
+		{"/api/orders", "text/csv", "id\n1", false, http.StatusOK},
+		{"/api/orders", "application/xml", "<id>1</id>", false, http.StatusUnsupportedMediaType},
+		{"/api/uploads", "application/octet-stream", strings.Repeat("x", 1024), true, http.StatusOK},
+	}
+
+	for _, c := range cases {
+		var body io.Reader = strings.NewReader(c.body)
+		if c.chunked {
+			body = reader{body}
+		}
+
+		w := post(c.path, c.contentType, body)
+		if w.Code != c.code {
+			t.Error("Status of", c.path, c.body, "should be", c.code, " Got", w.Code, w.Body.String())
+		}
+	}
+
+	if w := post("/plain", "text/plain", strings.NewReader("123456789")); w.Code != http.StatusRequestEntityTooLarge || reached != 0 {
+		t.Error("Bodies with a Content-Length over the limit should not reach the handler Got", w.Code, reached)
+	}
+	if w := post("/plain", "text/plain", reader{strings.NewReader("123456789")}); w.Code != http.StatusRequestEntityTooLarge || reached != 1 {
+		t.Error("Reading past the limit should be a 413 whatever the handler returns Got", w.Code, w.Body.String())
+	}
+
+	routes := router.Routes()
+	if strings.Join(routes[1].Requirements, ",") != "max-body-size:16,content-type:application/json,content-type:text/*" {
+		t.Error("Routes should list the body requirements Got", routes[1].Requirements)
+	}
+
+	fmt.Println("-- TestBodyLimits end --")
+}
@@ -0,0 +1,38 @@
+package router
+
+// RouteInfo describes a route, see Routes
+type RouteInfo struct {
+	Method  string
+	Pattern string
+	Handler string
+
+	// Interceptors are the base and route interceptors run for the route, in order
+	Interceptors []string
+
+	// Requirements are described by the interceptors implementing Describer, e.g. the roles or scopes required
+	Requirements []string
+}
+
+// Describer is implemented by interceptors that want what they require listed by Routes
+type Describer interface {
+	Requirements() []string
+}
+
+// Routes returns the routes in the order they were added, e.g. to document or audit them
+func (r *Router) Routes() []RouteInfo {
+	infos := make([]RouteInfo, 0, len(r.routes))
+	for _, route := range r.routes {
+		info := RouteInfo{Method: route.method, Pattern: route.pattern, Handler: route.name}
+
+		interceptors := append(r.baseInterceptorsOf(route.pattern), route.interceptors...)
+		for _, interceptor := range interceptors {
+			info.Interceptors = append(info.Interceptors, interceptorName(interceptor))
+			if describer, ok := interceptor.(Describer); ok {
+				info.Requirements = append(info.Requirements, describer.Requirements()...)
+			}
+		}
+
+		infos = append(infos, info)
+	}
+	return infos
+}
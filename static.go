@@ -0,0 +1,438 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticOptions configures how a static mount serves its files
+type StaticOptions struct {
+	// Extensions are the file extensions that can be served, e.g. ".html". Any extension is served if empty
+	Extensions []string
+
+	// MIMETypes maps file extensions to the Content-Type they are served with.
+	// Extensions not present use the standard mime types
+	MIMETypes map[string]string
+
+	// Index are the files served for a directory, in order. Defaults to index.html
+	Index []string
+
+	// ShowDotfiles allows serving files and directories whose name begins with '.'. They are not found by default
+	ShowDotfiles bool
+
+	// ListDirectories lists the content of directories without an index file. They are not found by default
+	ListDirectories bool
+
+	// Precompressed serves the .br or .gz sibling of a file, e.g. app.js.br, when the client Accept-Encoding allows it
+	Precompressed bool
+
+	// ETag sets a strong ETag computed from the file content, so clients can revalidate with If-None-Match
+	ETag bool
+
+	// Fallback is served for GET requests under the prefix that accept text/html and don't match any file,
+	// e.g. index.html for single page applications with client side routes like /app/orders/42.
+	// Requests for paths with an extension, like a missing app.js, are still not found
+	Fallback string
+
+	// FallbackExclude are path prefixes that never get the Fallback file, e.g. /api
+	FallbackExclude []string
+
+	// FingerprintMaxAge is the Cache-Control max-age of files with a content hash in the name, e.g. app.3f2a9c1b.js.
+	// They are also marked immutable. No Cache-Control is set if zero
+	FingerprintMaxAge time.Duration
+}
+
+// static is a file system mounted on a path prefix
+type static struct {
+	prefix  string
+	root    fs.FS
+	options StaticOptions
+	etags   sync.Map
+}
+
+// fallbackStatic is the implicit fallback enabled by Router.StaticFallback.
+// It serves html, css and js files from the working directory
+var fallbackStatic = &static{
+	prefix:  "/",
+	root:    &dirFS{dir: "."},
+	options: StaticOptions{Extensions: []string{".html", ".css", ".js"}},
+}
+
+// Static serves the files of root for GET and HEAD requests on paths beginning with prefix, when no route matches.
+// Request paths can't leave root, e.g. Static("/assets", os.DirFS("public")) serves /assets/app.js from public/app.js,
+// but os.DirFS follows the symlinks inside it. root can be an embed.FS, to serve files embedded in the binary
+func (r *Router) Static(prefix string, root fs.FS, options ...StaticOptions) {
+	if !strings.HasPrefix(prefix, "/") {
+		fmt.Println("[ERROR] prefix should ALWAYS begin with '/'")
+		panic("[ERROR] prefix should ALWAYS begin with '/'")
+	}
+
+	s := &static{prefix: strings.TrimSuffix(prefix, "/") + "/", root: root}
+	if len(options) > 0 {
+		s.options = options[0]
+	}
+	r.statics = append(r.statics, s)
+}
+
+// StaticDir serves the files of the directory dir on paths beginning with prefix. See Static.
+// Files are confined to dir, symlinks pointing outside of it are not followed
+func (r *Router) StaticDir(prefix string, dir string, options ...StaticOptions) {
+	r.Static(prefix, &dirFS{dir: dir}, options...)
+}
+
+// dirFS is the file system of a directory opened with os.OpenRoot, so it can't be escaped through symlinks.
+// The directory is opened on first use, as os.DirFS doesn't need it to exist when mounted
+type dirFS struct {
+	dir  string
+	once sync.Once
+	root fs.FS
+	err  error
+}
+
+// Open implements fs.FS
+func (d *dirFS) Open(name string) (fs.File, error) {
+	d.once.Do(func() {
+		root, err := os.OpenRoot(d.dir)
+		if err != nil {
+			d.err = err
+			return
+		}
+		d.root = root.FS()
+	})
+	if d.err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: d.err}
+	}
+	return d.root.Open(name)
+}
+
+// serveStatic serves rq from the first static mount that has the requested file.
+// Returns false if none of them has it
+func (r *Router) serveStatic(w http.ResponseWriter, rq *http.Request) bool {
+	if rq.Method != GET && rq.Method != http.MethodHead {
+		return false
+	}
+
+	statics := r.statics
+	if r.StaticFallback {
+		statics = append(statics[:len(statics):len(statics)], fallbackStatic)
+	}
+
+	for _, s := range statics {
+		if s.serve(w, rq) {
+			return true
+		}
+	}
+
+	// only when no mount has the file, so a SPA mounted on / doesn't hide the other mounts
+	for _, s := range statics {
+		if s.serveFallback(w, rq) {
+			return true
+		}
+	}
+	return false
+}
+
+// serve serves rq if the requested path is under the mount prefix and the file is allowed by the options.
+// Returns false if it isn't
+func (s *static) serve(w http.ResponseWriter, rq *http.Request) bool {
+	urlPath := rq.URL.Path
+	if urlPath+"/" == s.prefix {
+		urlPath += "/"
+	}
+	if !strings.HasPrefix(urlPath, s.prefix) {
+		return false
+	}
+
+	// path.Clean removes any '..', so the name never leaves the root
+	name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(urlPath, s.prefix)), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) || !s.visible(name) {
+		return false
+	}
+
+	info, err := fs.Stat(s.root, name)
+	if err != nil {
+		return false
+	}
+
+	if info.IsDir() {
+		return s.serveDir(w, rq, name)
+	}
+
+	if !s.allowed(name) {
+		return false
+	}
+	return s.serveFile(w, rq, name)
+}
+
+// serveFallback serves the Fallback file if rq is a navigation request under the mount prefix, see StaticOptions.Fallback
+func (s *static) serveFallback(w http.ResponseWriter, rq *http.Request) bool {
+	if s.options.Fallback == "" || !strings.HasPrefix(rq.URL.Path+"/", s.prefix) {
+		return false
+	}
+
+	for _, exclude := range s.options.FallbackExclude {
+		if rq.URL.Path == exclude || strings.HasPrefix(rq.URL.Path, strings.TrimSuffix(exclude, "/")+"/") {
+			return false
+		}
+	}
+
+	// missing assets are real not founds
+	if path.Ext(rq.URL.Path) != "" || !acceptsHTML(rq) {
+		return false
+	}
+
+	return s.serveFile(w, rq, s.options.Fallback)
+}
+
+// acceptsHTML reports whether the client explicitly accepts text/html, as browsers do when navigating
+func acceptsHTML(rq *http.Request) bool {
+	for _, ar := range parseAccept(rq.Header.Get("Accept")) {
+		if ar.mediaType == FormatHTML || ar.mediaType == "text/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// serveDir serves the index file of the directory or, if enabled, its listing
+func (s *static) serveDir(w http.ResponseWriter, rq *http.Request, name string) bool {
+	// relative links of the index and listing need the trailing slash
+	if !strings.HasSuffix(rq.URL.Path, "/") {
+		// relative, as an absolute target like //evil.com/ would redirect to another host
+		target := path.Base(rq.URL.Path) + "/"
+		if rq.URL.RawQuery != "" {
+			target += "?" + rq.URL.RawQuery
+		}
+		w.Header().Set("Location", target)
+		w.WriteHeader(http.StatusMovedPermanently)
+		return true
+	}
+
+	index := s.options.Index
+	if len(index) == 0 {
+		index = []string{"index.html"}
+	}
+
+	for _, file := range index {
+		indexName := path.Join(name, file)
+		if info, err := fs.Stat(s.root, indexName); err == nil && !info.IsDir() {
+			return s.serveFile(w, rq, indexName)
+		}
+	}
+
+	if !s.options.ListDirectories {
+		return false
+	}
+	return s.listDir(w, rq, name)
+}
+
+// serveFile writes the file with its Content-Type, handling Range and conditional requests.
+// A precompressed variant is written instead if the options and the client allow it
+func (s *static) serveFile(w http.ResponseWriter, rq *http.Request, name string) bool {
+	served := name
+	encoding := ""
+	if s.options.Precompressed {
+		w.Header().Add("Vary", "Accept-Encoding")
+		served, encoding = s.precompressed(rq, name)
+	}
+
+	f, err := s.root.Open(served)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return false
+		}
+		content = bytes.NewReader(data)
+	}
+
+	// the Content-Type is always the one of the requested file, not of the precompressed variant
+	contentType, ok := s.options.MIMETypes[path.Ext(name)]
+	if !ok {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	}
+
+	if s.options.ETag {
+		if etag, err := s.etag(served, info, content); err == nil {
+			w.Header().Set("ETag", etag)
+		}
+	}
+
+	if s.options.FingerprintMaxAge > 0 && fingerprinted(name) {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int(s.options.FingerprintMaxAge.Seconds())))
+	}
+
+	http.ServeContent(w, rq, name, info.ModTime(), content)
+	return true
+}
+
+// encodings are the precompressed variants looked for, in order of preference
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// precompressed returns the precompressed variant of name with the best encoding accepted by the client,
+// and the encoding. name itself and an empty encoding are returned if there is none
+func (s *static) precompressed(rq *http.Request, name string) (string, string) {
+	accepted := make(map[string]float64)
+	for _, ar := range parseAccept(rq.Header.Get("Accept-Encoding")) {
+		if _, ok := accepted[ar.mediaType]; !ok {
+			accepted[ar.mediaType] = ar.q
+		}
+	}
+
+	best, bestEncoding, bestQ := name, "", 0.0
+	for _, encoding := range encodings {
+		q, ok := accepted[encoding.name]
+		if !ok {
+			q = accepted["*"]
+		}
+		if q <= bestQ {
+			continue
+		}
+
+		if info, err := fs.Stat(s.root, name+encoding.ext); err == nil && !info.IsDir() {
+			best, bestEncoding, bestQ = name+encoding.ext, encoding.name, q
+		}
+	}
+	return best, bestEncoding
+}
+
+// etag returns the strong ETag of the file content, cached by name, size and modification time
+func (s *static) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := fmt.Sprintf("%s:%d:%d", name, info.Size(), info.ModTime().UnixNano())
+	if etag, ok := s.etags.Load(key); ok {
+		return etag.(string), nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	s.etags.Store(key, etag)
+	return etag, nil
+}
+
+// fingerprintRegex matches file names with a content hash, e.g. app.3f2a9c1b.js or app-3f2a9c1b.js
+var fingerprintRegex = regexp.MustCompile(`[.-][0-9a-fA-F]{8,}\.[^/]+$`)
+
+// fingerprinted reports whether the file name has a content hash, so its content never changes
+func fingerprinted(name string) bool {
+	return fingerprintRegex.MatchString(path.Base(name))
+}
+
+// listTemplate renders the listing of a directory
+var listTemplate = template.Must(template.New("list").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Path}}</title></head>
+<body>
+<h1>{{.Path}}</h1>
+<ul>
+{{range .Entries}}<li><a href="{{.}}">{{.}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// listDir writes the listing of the directory, without the entries that can't be served
+func (s *static) listDir(w http.ResponseWriter, rq *http.Request, name string) bool {
+	entries, err := fs.ReadDir(s.root, name)
+	if err != nil {
+		return false
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !s.options.ShowDotfiles && strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if entry.IsDir() {
+			names = append(names, entry.Name()+"/")
+		} else if s.allowed(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	if err := listTemplate.Execute(&buf, struct {
+		Path    string
+		Entries []string
+	}{rq.URL.Path, names}); err != nil {
+		return false
+	}
+
+	w.Header().Set("Content-Type", FormatHTML+"; charset=UTF-8")
+	w.Write(buf.Bytes())
+	return true
+}
+
+// visible reports whether name can be served according to the dotfiles option
+func (s *static) visible(name string) bool {
+	if s.options.ShowDotfiles {
+		return true
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") && segment != "." {
+			return false
+		}
+	}
+	return true
+}
+
+// allowed reports whether the extension of name can be served
+func (s *static) allowed(name string) bool {
+	if len(s.options.Extensions) == 0 {
+		return true
+	}
+	ext := path.Ext(name)
+	for _, allowed := range s.options.Extensions {
+		if strings.EqualFold(ext, allowed) {
+			return true
+		}
+	}
+	return false
+}